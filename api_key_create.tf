@@ -0,0 +1,20 @@
+data "archive_file" "api_key_create" {
+  type = "zip"
+  source_file = "api_key_create/api_key_create"
+  output_path = "api_key_create/${var.lambda_zip_file}"
+}
+
+resource "aws_lambda_function" "api_key_create" {
+  function_name = "api_key_create"
+  filename = data.archive_file.api_key_create.output_path
+  handler = "api_key_create"
+  source_code_hash = data.archive_file.api_key_create.output_base64sha256
+  runtime = "go1.x"
+  memory_size = 128
+  timeout = 10
+  role = aws_iam_role.iam_for_lambda.arn
+
+  environment {
+    variables = local.auth_environment
+  }
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/go-playground/validator/v10"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+type Request events.APIGatewayProxyRequest
+type Response events.APIGatewayProxyResponse
+
+// Handler serves api_key_create requests using the keys held in an
+// APIKeyRepository.
+type Handler struct {
+	keys xeffect.APIKeyRepository
+}
+
+// New returns a Handler backed by keys.
+func New(keys xeffect.APIKeyRepository) *Handler {
+	return &Handler{keys: keys}
+}
+
+type NewAPIKey struct {
+	Name   string   `json:"name" validate:"required"`
+	Scopes []string `json:"scopes" validate:"omitempty,unique,dive,oneof=read mark_completed"`
+}
+
+// CreatedAPIKey is a newly created API key, along with the key itself, which is
+// only ever returned when the key is created.
+type CreatedAPIKey struct {
+	xeffect.APIKey
+	Key string `json:"key"`
+}
+
+func returnError(err error) (Response, error) {
+	return Response{
+		StatusCode:      400,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "text/plain",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: err.Error(),
+	}, nil
+}
+
+func returnUnauthorized() (Response, error) {
+	response, err := xeffect.UnauthorizedResponse()
+	return Response(response), err
+}
+
+func (h *Handler) HandleAPIKeyCreationEvent(ctx context.Context, event Request) (Response, error) {
+	owner, ok := xeffect.RequestOwner(event.RequestContext)
+	if !ok {
+		return returnUnauthorized()
+	}
+
+	var body []byte
+	if event.IsBase64Encoded {
+		var err error
+		body, err = base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			return returnError(err)
+		}
+	} else {
+		body = []byte(event.Body)
+	}
+
+	contentType := event.Headers["content-type"]
+	if contentType != "application/json" {
+		return returnError(fmt.Errorf("'%s' is not a supported Content-Type", contentType))
+	}
+
+	var newKey NewAPIKey
+	if err := json.Unmarshal(body, &newKey); err != nil {
+		return returnError(err)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(newKey); err != nil {
+		return returnError(err)
+	}
+
+	key, secret, err := xeffect.NewAPIKey(newKey.Name, newKey.Scopes)
+	if err != nil {
+		return returnError(err)
+	}
+	key.CreatedAt = time.Now().UTC().Format("2006-01-02")
+
+	if err := h.keys.ForOwner(owner).CreateAPIKey(ctx, key); err != nil {
+		return returnError(err)
+	}
+
+	responseBody, err := json.Marshal(CreatedAPIKey{
+		APIKey: key,
+		Key:    secret,
+	})
+	if err != nil {
+		return returnError(err)
+	}
+
+	return Response{
+		StatusCode:      201,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
@@ -0,0 +1,20 @@
+data "archive_file" "api_key_delete" {
+  type = "zip"
+  source_file = "api_key_delete/api_key_delete"
+  output_path = "api_key_delete/${var.lambda_zip_file}"
+}
+
+resource "aws_lambda_function" "api_key_delete" {
+  function_name = "api_key_delete"
+  filename = data.archive_file.api_key_delete.output_path
+  handler = "api_key_delete"
+  source_code_hash = data.archive_file.api_key_delete.output_base64sha256
+  runtime = "go1.x"
+  memory_size = 128
+  timeout = 10
+  role = aws_iam_role.iam_for_lambda.arn
+
+  environment {
+    variables = local.auth_environment
+  }
+}
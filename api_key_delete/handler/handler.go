@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+type Request events.APIGatewayProxyRequest
+type Response events.APIGatewayProxyResponse
+
+// Handler serves api_key_delete requests using the keys held in an
+// APIKeyRepository.
+type Handler struct {
+	keys xeffect.APIKeyRepository
+}
+
+// New returns a Handler backed by keys.
+func New(keys xeffect.APIKeyRepository) *Handler {
+	return &Handler{keys: keys}
+}
+
+func returnError(err error) (Response, error) {
+	return Response{
+		StatusCode:      400,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "text/plain",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: err.Error(),
+	}, nil
+}
+
+func returnErrorResponse(statusCode int, code string, message string) (Response, error) {
+	response, err := xeffect.JSONErrorResponse(statusCode, code, message)
+	return Response(response), err
+}
+
+func returnNotFound(keyId string) (Response, error) {
+	return returnErrorResponse(404, "api_key_not_found", fmt.Sprintf("API key '%s' does not exist.", keyId))
+}
+
+func returnUnauthorized() (Response, error) {
+	response, err := xeffect.UnauthorizedResponse()
+	return Response(response), err
+}
+
+// HandleAPIKeyDeleteEvent revokes an API key, which can not be used again.
+func (h *Handler) HandleAPIKeyDeleteEvent(ctx context.Context, event Request) (Response, error) {
+	owner, ok := xeffect.RequestOwner(event.RequestContext)
+	if !ok {
+		return returnUnauthorized()
+	}
+
+	keyId := event.PathParameters["keyId"]
+
+	err := h.keys.ForOwner(owner).DeleteAPIKey(ctx, keyId)
+	if errors.Is(err, xeffect.ErrAPIKeyNotFound) {
+		return returnNotFound(keyId)
+	}
+
+	if err != nil {
+		return returnError(err)
+	}
+
+	return Response{
+		StatusCode: 204,
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin": "*",
+		},
+	}, nil
+}
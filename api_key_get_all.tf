@@ -0,0 +1,20 @@
+data "archive_file" "api_key_get_all" {
+  type = "zip"
+  source_file = "api_key_get_all/api_key_get_all"
+  output_path = "api_key_get_all/${var.lambda_zip_file}"
+}
+
+resource "aws_lambda_function" "api_key_get_all" {
+  function_name = "api_key_get_all"
+  filename = data.archive_file.api_key_get_all.output_path
+  handler = "api_key_get_all"
+  source_code_hash = data.archive_file.api_key_get_all.output_base64sha256
+  runtime = "go1.x"
+  memory_size = 128
+  timeout = 10
+  role = aws_iam_role.iam_for_lambda.arn
+
+  environment {
+    variables = local.auth_environment
+  }
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+type Request events.APIGatewayProxyRequest
+type Response events.APIGatewayProxyResponse
+
+// Handler serves api_key_get_all requests using the keys held in an
+// APIKeyRepository.
+type Handler struct {
+	keys xeffect.APIKeyRepository
+}
+
+// New returns a Handler backed by keys.
+func New(keys xeffect.APIKeyRepository) *Handler {
+	return &Handler{keys: keys}
+}
+
+type APIKeys struct {
+	APIKeys []xeffect.APIKey `json:"api_keys"`
+}
+
+func returnError(err error) (Response, error) {
+	return Response{
+		StatusCode:      400,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "text/plain",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: err.Error(),
+	}, nil
+}
+
+func returnUnauthorized() (Response, error) {
+	response, err := xeffect.UnauthorizedResponse()
+	return Response(response), err
+}
+
+func (h *Handler) HandleAPIKeyGetAllEvent(ctx context.Context, event Request) (Response, error) {
+	owner, ok := xeffect.RequestOwner(event.RequestContext)
+	if !ok {
+		return returnUnauthorized()
+	}
+
+	keys, err := h.keys.ForOwner(owner).ListAPIKeys(ctx)
+	if err != nil {
+		return returnError(err)
+	}
+
+	body, err := json.Marshal(APIKeys{
+		APIKeys: keys,
+	})
+	if err != nil {
+		return returnError(err)
+	}
+
+	return Response{
+		StatusCode:      200,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(body),
+	}, nil
+}
@@ -14,4 +14,75 @@ resource "aws_dynamodb_table" "xeffect" {
     name = "uuid"
     type = "S"
   }
+
+  attribute {
+    name = "Owner"
+    type = "S"
+  }
+
+  # Lists the goals of a single user.
+  global_secondary_index {
+    name = "owner_index"
+    hash_key = "Owner"
+    projection_type = "ALL"
+    read_capacity = 1
+    write_capacity = 1
+  }
+}
+
+variable "xeffect_api_keys_table" {
+  type = string
+  default = "xeffect_api_keys"
+}
+
+resource "aws_dynamodb_table" "xeffect_api_keys" {
+  name = var.xeffect_api_keys_table
+  hash_key = "uuid"
+  billing_mode = "PROVISIONED"
+  read_capacity = 1
+  write_capacity = 1
+
+  attribute {
+    name = "uuid"
+    type = "S"
+  }
+
+  attribute {
+    name = "Owner"
+    type = "S"
+  }
+
+  # Lists the API keys of a single user.
+  global_secondary_index {
+    name = "owner_index"
+    hash_key = "Owner"
+    projection_type = "ALL"
+    read_capacity = 1
+    write_capacity = 1
+  }
+}
+
+variable "xeffect_completion_events_table" {
+  type = string
+  default = "xeffect_completion_events"
+}
+
+# Every change to the completed days of a goal, in the order it was made.
+resource "aws_dynamodb_table" "xeffect_completion_events" {
+  name = var.xeffect_completion_events_table
+  hash_key = "goal_uuid"
+  range_key = "sequence"
+  billing_mode = "PROVISIONED"
+  read_capacity = 1
+  write_capacity = 1
+
+  attribute {
+    name = "goal_uuid"
+    type = "S"
+  }
+
+  attribute {
+    name = "sequence"
+    type = "N"
+  }
 }
@@ -0,0 +1,795 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/go-playground/validator/v10"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+type Request events.APIGatewayProxyRequest
+type Response events.APIGatewayProxyResponse
+
+// MAX_BULK_DAYS is the largest number of days a single bulk action may change.
+const MAX_BULK_DAYS = 366
+
+// MAX_UNDO_HISTORY is the number of changes to the completed days of a goal
+// which can be undone.
+const MAX_UNDO_HISTORY = 10
+
+// MAX_ACTION_ATTEMPTS is the number of times an action is tried when the goal
+// keeps being changed by other requests between being read and written.
+const MAX_ACTION_ATTEMPTS = 3
+
+// Handler serves goal_action requests using the goals held in a GoalRepository.
+type Handler struct {
+	goals xeffect.GoalRepository
+}
+
+// New returns a Handler backed by goals.
+func New(goals xeffect.GoalRepository) *Handler {
+	return &Handler{goals: goals}
+}
+
+// ErrNothingToUndo is returned by the undo action when the goal has no changes
+// left to undo.
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// Goal is a goal, as it is after an action, along with a summary of its
+// streaks.
+type Goal struct {
+	xeffect.Goal
+	Summary *xeffect.GoalSummary `json:"summary"`
+}
+
+type GoalAction struct {
+	Type string `json:"action" validate:"required"`
+}
+
+// GoalActionHandler is implemented by the payload of each action, and applies
+// the action to the goal once the payload has been unmarshalled and validated,
+// returning the goal as the action left it. The actor is recorded against any
+// completion events the action makes.
+type GoalActionHandler interface {
+	Apply(ctx context.Context, goals xeffect.GoalRepository, id string, actor string) (xeffect.Goal, error)
+}
+
+// goalActions maps each action type to a constructor for its payload.
+var goalActions = map[string]func() GoalActionHandler{
+	"mark_completed":      func() GoalActionHandler { return &GoalMarkCompleted{} },
+	"mark_completed_bulk": func() GoalActionHandler { return &GoalMarkCompletedBulk{} },
+	"archive":             func() GoalActionHandler { return &GoalArchive{} },
+	"restore":             func() GoalActionHandler { return &GoalRestore{} },
+	"undo":                func() GoalActionHandler { return &GoalUndo{} },
+}
+
+// GoalArchive hides a goal from the list of goals, without removing its history.
+type GoalArchive struct{}
+
+// GoalRestore returns an archived goal to the list of goals.
+type GoalRestore struct{}
+
+// GoalUndo reverts the most recent change to the completed days of a goal,
+// restoring its streaks as they were before the change.
+type GoalUndo struct{}
+
+type GoalMarkCompleted struct {
+	IsCompleted *bool  `json:"is_completed" validate:"required"`
+	Date        string `json:"date" validate:"required,datetime=2006-01-02"`
+	// StreakStartDate string `json:"streak_start_date"`
+}
+
+// GoalMarkCompletedBulk marks many days at once, given either as a list of
+// Dates, or as the range From to To inclusive.
+type GoalMarkCompletedBulk struct {
+	IsCompleted *bool    `json:"is_completed" validate:"required"`
+	Dates       []string `json:"dates" validate:"required_without_all=From To,excluded_with=From To,omitempty,max=366,dive,datetime=2006-01-02"`
+	From        string   `json:"from" validate:"required_with=To,omitempty,datetime=2006-01-02"`
+	To          string   `json:"to" validate:"required_with=From,omitempty,datetime=2006-01-02"`
+}
+
+func returnError(err error) (Response, error) {
+	return Response{
+		StatusCode:      400,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "text/plain",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: err.Error(),
+	}, nil
+}
+
+func returnErrorResponse(statusCode int, code string, message string) (Response, error) {
+	response, err := xeffect.JSONErrorResponse(statusCode, code, message)
+	return Response(response), err
+}
+
+func returnNotFound(goalId string) (Response, error) {
+	return returnErrorResponse(404, "goal_not_found", fmt.Sprintf("Goal '%s' does not exist.", goalId))
+}
+
+func returnUnauthorized() (Response, error) {
+	response, err := xeffect.UnauthorizedResponse()
+	return Response(response), err
+}
+
+func returnConflict(goalId string) (Response, error) {
+	return returnErrorResponse(409, "goal_conflict", fmt.Sprintf("Goal '%s' is being changed by another request, please try again.", goalId))
+}
+
+func returnUnsupportedAction(actionType string) (Response, error) {
+	supportedActions := make([]string, 0, len(goalActions))
+	for name := range goalActions {
+		supportedActions = append(supportedActions, name)
+	}
+	sort.Strings(supportedActions)
+
+	return returnErrorResponse(400, "unsupported_action", fmt.Sprintf("'%s' is not a supported action. Supported actions are: %s.", actionType, strings.Join(supportedActions, ", ")))
+}
+
+func (h *Handler) HandleGoalActionEvent(ctx context.Context, event Request) (Response, error) {
+	owner, ok := xeffect.RequestOwner(event.RequestContext)
+	if !ok {
+		return returnUnauthorized()
+	}
+
+	actor, _ := xeffect.RequestActor(event.RequestContext)
+
+	goalId := event.PathParameters["goalId"]
+
+	// The date the summary of the updated goal is worked out as of.
+	date := event.QueryStringParameters["date"]
+	if date == "" {
+		date = time.Now().UTC().Format("2006-01-02")
+	}
+
+	if _, err := parseDate(date); err != nil {
+		return returnError(err)
+	}
+
+	var body []byte
+	if event.IsBase64Encoded {
+		var err error
+		body, err = base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			return returnError(err)
+		}
+	} else {
+		body = []byte(event.Body)
+	}
+
+	contentType := event.Headers["content-type"]
+	if contentType != "application/json" {
+		headers, _ := json.Marshal(event.Headers)
+		return returnError(fmt.Errorf("'%s' is not a supported Content-Type.\n%s", contentType, string(headers)))
+	}
+
+	var action GoalAction
+	if err := json.Unmarshal(body, &action); err != nil {
+		return returnError(err)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(action); err != nil {
+		return returnError(err)
+	}
+
+	newActionHandler, ok := goalActions[action.Type]
+	if !ok {
+		return returnUnsupportedAction(action.Type)
+	}
+
+	actionHandler := newActionHandler()
+	if err := json.Unmarshal(body, actionHandler); err != nil {
+		return returnError(err)
+	}
+
+	if err := validate.Struct(actionHandler); err != nil {
+		return returnError(err)
+	}
+
+	// Each attempt reads the goal afresh, so an action which conflicted with
+	// another request is decided again from the goal as that request left it.
+	var (
+		updated xeffect.Goal
+		err     error
+	)
+	for attempt := 0; attempt < MAX_ACTION_ATTEMPTS; attempt++ {
+		updated, err = actionHandler.Apply(ctx, h.goals.ForOwner(owner), goalId, actor)
+		if !errors.Is(err, xeffect.ErrVersionConflict) {
+			break
+		}
+	}
+
+	if errors.Is(err, xeffect.ErrGoalNotFound) {
+		return returnNotFound(goalId)
+	}
+
+	if errors.Is(err, xeffect.ErrVersionConflict) {
+		return returnConflict(goalId)
+	}
+
+	if errors.Is(err, ErrNothingToUndo) {
+		return returnErrorResponse(400, "nothing_to_undo", fmt.Sprintf("Goal '%s' has no changes to undo.", goalId))
+	}
+
+	if err != nil {
+		return returnError(err)
+	}
+
+	goal := Goal{
+		Goal: updated,
+	}
+
+	summary, err := xeffect.SummariseGoal(goal.Goal, date)
+	if err != nil {
+		return returnError(err)
+	}
+	goal.Summary = &summary
+
+	responseBody, err := json.Marshal(goal)
+	if err != nil {
+		return returnError(err)
+	}
+
+	return Response{
+		StatusCode:      200,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
+
+func parseDate(date string) (time.Time, error) {
+	return time.Parse("2006-01-02", date)
+}
+
+func daysBetweenDates(earlierDate string, laterDate string) (int, error) {
+	a, err := parseDate(earlierDate)
+	if err != nil {
+		return 0, err
+	}
+
+	b, err := parseDate(laterDate)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(b.Sub(a).Hours() / 24), nil
+}
+
+// calculateBestStreak returns the length of the longest streak once update has
+// been applied to streaks.
+func calculateBestStreak(streaks map[string]xeffect.GoalStreak, update xeffect.StreakUpdate) int {
+	best := 0
+	for _, streak := range update.Set {
+		if streak.Length > best {
+			best = streak.Length
+		}
+	}
+
+	if update.Replace {
+		return best
+	}
+
+	removed := map[string]bool{}
+	for _, streakDate := range update.Remove {
+		removed[streakDate] = true
+	}
+
+	for streakDate, streak := range streaks {
+		if _, ok := update.Set[streakDate]; ok || removed[streakDate] {
+			continue
+		}
+
+		if streak.Length > best {
+			best = streak.Length
+		}
+	}
+
+	return best
+}
+
+// newCompletionEvent returns the event recording dates being marked by actor.
+func newCompletionEvent(actor string, dates []string, completed bool) xeffect.CompletionEvent {
+	return xeffect.CompletionEvent{
+		Dates:     dates,
+		Completed: completed,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Actor:     actor,
+	}
+}
+
+// pushUndoHistory returns the undo history of goal with its current streaks
+// added, dropping the oldest streaks once there are more than MAX_UNDO_HISTORY.
+func pushUndoHistory(goal xeffect.Goal) []xeffect.StreakSnapshot {
+	history := append([]xeffect.StreakSnapshot{}, goal.UndoHistory...)
+	history = append(history, xeffect.StreakSnapshot{
+		Streaks:     goal.Streaks,
+		StreakDates: goal.StreakDates,
+		BestStreak:  goal.BestStreak,
+	})
+
+	if len(history) > MAX_UNDO_HISTORY {
+		history = history[len(history)-MAX_UNDO_HISTORY:]
+	}
+
+	return history
+}
+
+// updateStreaks writes update, along with the best streak of the goal once the
+// update has been made and the event which caused it. The streaks before the
+// update are added to the undo history. The update is only made if goal is
+// still the latest version, and the goal is returned as the update left it.
+func updateStreaks(ctx context.Context, goals xeffect.GoalRepository, id string, goal xeffect.Goal, event xeffect.CompletionEvent, update xeffect.StreakUpdate) (xeffect.Goal, error) {
+	update.BestStreak = calculateBestStreak(goal.Streaks, update)
+	update.Version = goal.Version
+	update.UndoHistory = pushUndoHistory(goal)
+
+	events, err := xeffect.LogCompletion(goal, event)
+	if err != nil {
+		return xeffect.Goal{}, err
+	}
+	update.Events = events
+
+	if err := goals.UpdateStreaks(ctx, id, update); err != nil {
+		return xeffect.Goal{}, err
+	}
+
+	return xeffect.ApplyStreakUpdate(goal, update), nil
+}
+
+func dateInStreak(streakDate string, streakLength int, date string) (bool, error) {
+	days, err := daysBetweenDates(streakDate, date)
+	if err != nil {
+		return false, err
+	}
+
+	inStreak := false
+	if days < streakLength {
+		inStreak = true
+	}
+
+	return inStreak, nil
+}
+
+// insertStreakDate returns a copy of streakDates with date inserted at index.
+func insertStreakDate(streakDates []string, index int, date string) []string {
+	streaks := make([]string, 0, len(streakDates)+1)
+	streaks = append(streaks, streakDates[:index]...)
+	streaks = append(streaks, date)
+	streaks = append(streaks, streakDates[index:]...)
+
+	return streaks
+}
+
+// replaceStreakDate returns a copy of streakDates with the date at index
+// replaced by date.
+func replaceStreakDate(streakDates []string, index int, date string) []string {
+	streaks := append([]string{}, streakDates...)
+	streaks[index] = date
+
+	return streaks
+}
+
+// removeStreakDate returns a copy of streakDates without the date at index.
+func removeStreakDate(streakDates []string, index int) []string {
+	streaks := make([]string, 0, len(streakDates)-1)
+	streaks = append(streaks, streakDates[:index]...)
+	streaks = append(streaks, streakDates[index+1:]...)
+
+	return streaks
+}
+
+func (action *GoalMarkCompleted) Apply(ctx context.Context, goals xeffect.GoalRepository, id string, actor string) (xeffect.Goal, error) {
+	return goalMarkCompleted(ctx, goals, id, actor, *action)
+}
+
+// goalMarkCompleted decides how the streaks of a goal change when a single day
+// is marked, and writes the change as a single streak update, so that the
+// streaks and streak dates of the goal change together or not at all. Marking a
+// day which is already marked as asked changes nothing, and records no event.
+// The streaks of a goal with a schedule may skip days, and marking a single day
+// can change which days of its week may be skipped, so they are laid out again
+// from the completed days.
+func goalMarkCompleted(ctx context.Context, goals xeffect.GoalRepository, id string, actor string, action GoalMarkCompleted) (xeffect.Goal, error) {
+	goal, err := goals.GetGoal(ctx, id)
+	if err != nil {
+		return xeffect.Goal{}, err
+	}
+
+	event := newCompletionEvent(actor, []string{action.Date}, *action.IsCompleted)
+
+	if goal.Schedule != nil {
+		days, err := xeffect.CompletedDays(goal)
+		if err != nil {
+			return xeffect.Goal{}, err
+		}
+
+		if days[action.Date] == *action.IsCompleted {
+			return goal, nil
+		}
+
+		return markDays(ctx, goals, id, goal, event)
+	}
+
+	var i int
+	for i = 0; i < len(goal.StreakDates); i++ {
+		index := goal.StreakDates[i]
+		indexDate, err := parseDate(index)
+		if err != nil {
+			return xeffect.Goal{}, err
+		}
+
+		actionDate, err := parseDate(action.Date)
+		if err != nil {
+			return xeffect.Goal{}, err
+		}
+
+		// If the action date is further in the past than the index,
+		// the in cannot be in that streak.
+		if indexDate.After(actionDate) {
+			continue
+		}
+
+		streak := goal.Streaks[index]
+		inStreak, err := dateInStreak(index, streak.Length, action.Date)
+		if err != nil {
+			return xeffect.Goal{}, err
+		}
+
+		// Option Matrix:
+		// 0. Not In Streak & Is Not Complete - No Action.
+		// 1. In Streak & Is Complete - No Action.
+		// 2. In Streak & Is Not Complete - Split the streak and remove the non-complete
+		// date.
+		// 3. 1 After Streak & Is Complete - Modify the neighbour streak to include the new
+		// completion.
+		// 4. 1 Before Previous Streak & Is Complete - Modify the previous streak to start at the new date.
+		// 5. Not In Streak & Is Completed - Create new Streak.
+
+		// 0. The date is not within the streak, and is not completed, so no changes need to be made.
+		if !inStreak && !*action.IsCompleted {
+			return goal, nil
+		}
+
+		if inStreak {
+			if *action.IsCompleted {
+				// 1. The goal is already complete on this date.
+				return goal, nil
+			}
+
+			// 2. Split the streak into two, and remove the completion of the specified
+			// day.
+			daysBetween, err := daysBetweenDates(index, action.Date)
+			if err != nil {
+				return xeffect.Goal{}, err
+			}
+
+			// If the streak is only the date to be removed, the streak is removed.
+			if streak.Length == 1 {
+				return updateStreaks(ctx, goals, id, goal, event, xeffect.StreakUpdate{
+					Remove:      []string{index},
+					StreakDates: removeStreakDate(goal.StreakDates, i),
+				})
+			}
+
+			// If the date to be removed is the last date in a streak.
+			if daysBetween == streak.Length-1 {
+				// Decrement the streak.
+				return updateStreaks(ctx, goals, id, goal, event, xeffect.StreakUpdate{
+					Set: map[string]xeffect.GoalStreak{
+						index: {Length: streak.Length - 1},
+					},
+					StreakDates: goal.StreakDates,
+				})
+			}
+
+			// If the date to be removed is the first date in a streak, the streak now
+			// starts on the following day.
+			if daysBetween == 0 {
+				newStreakDate := actionDate.AddDate(0, 0, 1).Format("2006-01-02")
+				return updateStreaks(ctx, goals, id, goal, event, xeffect.StreakUpdate{
+					Set: map[string]xeffect.GoalStreak{
+						newStreakDate: {Length: streak.Length - 1},
+					},
+					Remove:      []string{index},
+					StreakDates: replaceStreakDate(goal.StreakDates, i, newStreakDate),
+				})
+			}
+
+			// Otherwise the date is in the middle of the streak, so the streak is
+			// shortened to end on the day before the date, and a new streak is started
+			// on the day after, taking the remainder of the original streak.
+			// The streak dates are ordered most recent first, so the new (later) streak
+			// takes the place of the original, which moves one index further on.
+			newStreakDate := actionDate.AddDate(0, 0, 1).Format("2006-01-02")
+			return updateStreaks(ctx, goals, id, goal, event, xeffect.StreakUpdate{
+				Set: map[string]xeffect.GoalStreak{
+					index:         {Length: daysBetween},
+					newStreakDate: {Length: streak.Length - daysBetween - 1},
+				},
+				StreakDates: insertStreakDate(goal.StreakDates, i, newStreakDate),
+			})
+		}
+
+		// Check whether incrementing this streak now requires the streak to be
+		// merged into the next.
+		var (
+			previousIndex     string
+			previousIndexDate time.Time
+		)
+		if i > 0 {
+			previousIndex = goal.StreakDates[i-1]
+			previousIndexDate, err = parseDate(previousIndex)
+			if err != nil {
+				return xeffect.Goal{}, err
+			}
+		}
+
+		// 3. If the a goal has been completed on the next available streak day,
+		// then increment the streak.
+		// nextDayInStreak := indexDate.Add(time.Hour * time.Duration(streak.Length))
+		nextDayInStreak := indexDate.AddDate(0, 0, streak.Length)
+		if actionDate.Equal(nextDayInStreak) && *action.IsCompleted {
+			// If the previous streak (the one that is the next most in the future)
+			// falls on the day after the action date, then the streak is merged with
+			// it in the same update, so that the goal is never left with two streaks
+			// which should be one.
+			if i > 0 && previousIndexDate.Sub(actionDate).Hours() == 24 {
+				previousStreak := goal.Streaks[previousIndex]
+				return updateStreaks(ctx, goals, id, goal, event, xeffect.StreakUpdate{
+					Set: map[string]xeffect.GoalStreak{
+						index: {Length: streak.Length + 1 + previousStreak.Length},
+					},
+					Remove:      []string{previousIndex},
+					StreakDates: removeStreakDate(goal.StreakDates, i-1),
+				})
+			}
+
+			return updateStreaks(ctx, goals, id, goal, event, xeffect.StreakUpdate{
+				Set: map[string]xeffect.GoalStreak{
+					index: {Length: streak.Length + 1},
+				},
+				StreakDates: goal.StreakDates,
+			})
+		}
+
+		// 4. Check if the completion can be added to the start of the previous streak.
+		if i > 0 {
+			previousDateInLastStreak := previousIndexDate.AddDate(0, 0, -1)
+			if actionDate.Equal(previousDateInLastStreak) {
+				return updateStreaks(ctx, goals, id, goal, event, xeffect.StreakUpdate{
+					Set: map[string]xeffect.GoalStreak{
+						action.Date: {Length: goal.Streaks[previousIndex].Length + 1},
+					},
+					Remove:      []string{previousIndex},
+					StreakDates: replaceStreakDate(goal.StreakDates, i-1, action.Date),
+				})
+			}
+		}
+
+		// If this statement is reached then the completion matches no current streaks.
+		// So a new streak should be created.
+		break
+	}
+
+	// 0. The date is not within any streak, and is not completed, so no changes
+	// need to be made.
+	if !*action.IsCompleted {
+		return goal, nil
+	}
+
+	// 4. When the date is before every streak, the loop ends without checking
+	// whether the completion can be added to the start of the oldest streak.
+	if i > 0 && i == len(goal.StreakDates) {
+		oldestIndex := goal.StreakDates[i-1]
+		oldestIndexDate, err := parseDate(oldestIndex)
+		if err != nil {
+			return xeffect.Goal{}, err
+		}
+
+		actionDate, err := parseDate(action.Date)
+		if err != nil {
+			return xeffect.Goal{}, err
+		}
+
+		if actionDate.Equal(oldestIndexDate.AddDate(0, 0, -1)) {
+			return updateStreaks(ctx, goals, id, goal, event, xeffect.StreakUpdate{
+				Set: map[string]xeffect.GoalStreak{
+					action.Date: {Length: goal.Streaks[oldestIndex].Length + 1},
+				},
+				Remove:      []string{oldestIndex},
+				StreakDates: replaceStreakDate(goal.StreakDates, i-1, action.Date),
+			})
+		}
+	}
+
+	// 5. Create new streak.
+	return updateStreaks(ctx, goals, id, goal, event, xeffect.StreakUpdate{
+		Set: map[string]xeffect.GoalStreak{
+			action.Date: {Length: 1},
+		},
+		StreakDates: insertStreakDate(goal.StreakDates, i, action.Date),
+	})
+}
+
+// dates returns every date the bulk action applies to.
+func (action *GoalMarkCompletedBulk) dates() ([]string, error) {
+	if len(action.Dates) > 0 {
+		return action.Dates, nil
+	}
+
+	from, err := parseDate(action.From)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := parseDate(action.To)
+	if err != nil {
+		return nil, err
+	}
+
+	if to.Before(from) {
+		return nil, fmt.Errorf("'%s' is before '%s'", action.To, action.From)
+	}
+
+	dates := []string{}
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		if len(dates) == MAX_BULK_DAYS {
+			return nil, fmt.Errorf("at most %d days may be changed at once", MAX_BULK_DAYS)
+		}
+
+		dates = append(dates, date.Format("2006-01-02"))
+	}
+
+	return dates, nil
+}
+
+func (action *GoalMarkCompletedBulk) Apply(ctx context.Context, goals xeffect.GoalRepository, id string, actor string) (xeffect.Goal, error) {
+	dates, err := action.dates()
+	if err != nil {
+		return xeffect.Goal{}, err
+	}
+
+	goal, err := goals.GetGoal(ctx, id)
+	if err != nil {
+		return xeffect.Goal{}, err
+	}
+
+	event := newCompletionEvent(actor, dates, *action.IsCompleted)
+
+	return markDays(ctx, goals, id, goal, event)
+}
+
+// markDays marks the dates of event on goal by working out the new streak
+// layout from the completed days, so that it can be written in a single update
+// however many streaks the dates join or split.
+func markDays(ctx context.Context, goals xeffect.GoalRepository, id string, goal xeffect.Goal, event xeffect.CompletionEvent) (xeffect.Goal, error) {
+	days, err := xeffect.CompletedDays(goal)
+	if err != nil {
+		return xeffect.Goal{}, err
+	}
+
+	for _, date := range event.Dates {
+		if event.Completed {
+			days[date] = true
+		} else {
+			delete(days, date)
+		}
+	}
+
+	streaks, streakDates, err := xeffect.StreaksFromDays(days, goal.Schedule)
+	if err != nil {
+		return xeffect.Goal{}, err
+	}
+
+	return updateStreaks(ctx, goals, id, goal, event, xeffect.StreakUpdate{
+		Set:         streaks,
+		Replace:     true,
+		StreakDates: streakDates,
+	})
+}
+
+// Apply restores the most recent streaks in the undo history, recording the days
+// this completes and un-completes as events.
+func (action *GoalUndo) Apply(ctx context.Context, goals xeffect.GoalRepository, id string, actor string) (xeffect.Goal, error) {
+	goal, err := goals.GetGoal(ctx, id)
+	if err != nil {
+		return xeffect.Goal{}, err
+	}
+
+	if len(goal.UndoHistory) == 0 {
+		return xeffect.Goal{}, ErrNothingToUndo
+	}
+	previous := goal.UndoHistory[len(goal.UndoHistory)-1]
+
+	days, err := xeffect.CompletedDays(goal)
+	if err != nil {
+		return xeffect.Goal{}, err
+	}
+
+	restoredDays, err := xeffect.CompletedDays(xeffect.Goal{Streaks: previous.Streaks})
+	if err != nil {
+		return xeffect.Goal{}, err
+	}
+
+	completed := []string{}
+	for date := range restoredDays {
+		if !days[date] {
+			completed = append(completed, date)
+		}
+	}
+	sort.Strings(completed)
+
+	uncompleted := []string{}
+	for date := range days {
+		if !restoredDays[date] {
+			uncompleted = append(uncompleted, date)
+		}
+	}
+	sort.Strings(uncompleted)
+
+	changes := []xeffect.CompletionEvent{}
+	if len(completed) > 0 {
+		event := newCompletionEvent(actor, completed, true)
+		event.Undo = true
+		changes = append(changes, event)
+	}
+	if len(uncompleted) > 0 {
+		event := newCompletionEvent(actor, uncompleted, false)
+		event.Undo = true
+		changes = append(changes, event)
+	}
+
+	events, err := xeffect.LogCompletion(goal, changes...)
+	if err != nil {
+		return xeffect.Goal{}, err
+	}
+
+	update := xeffect.StreakUpdate{
+		Set:         previous.Streaks,
+		Replace:     true,
+		StreakDates: previous.StreakDates,
+		BestStreak:  previous.BestStreak,
+		Version:     goal.Version,
+		Events:      events,
+		UndoHistory: goal.UndoHistory[:len(goal.UndoHistory)-1],
+	}
+	if err := goals.UpdateStreaks(ctx, id, update); err != nil {
+		return xeffect.Goal{}, err
+	}
+
+	return xeffect.ApplyStreakUpdate(goal, update), nil
+}
+
+func (action *GoalArchive) Apply(ctx context.Context, goals xeffect.GoalRepository, id string, actor string) (xeffect.Goal, error) {
+	return setArchived(ctx, goals, id, true)
+}
+
+func (action *GoalRestore) Apply(ctx context.Context, goals xeffect.GoalRepository, id string, actor string) (xeffect.Goal, error) {
+	return setArchived(ctx, goals, id, false)
+}
+
+// setArchived archives or restores a goal as long as it is not changed by
+// another request at the same time.
+func setArchived(ctx context.Context, goals xeffect.GoalRepository, id string, archived bool) (xeffect.Goal, error) {
+	goal, err := goals.GetGoal(ctx, id)
+	if err != nil {
+		return xeffect.Goal{}, err
+	}
+
+	return goals.SetGoalArchived(ctx, id, archived, goal.Version)
+}
@@ -0,0 +1,280 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/maxstanley/xeffect_backend/xeffect"
+	"github.com/maxstanley/xeffect_backend/xeffect/xeffecttest"
+)
+
+// newTestHandler returns a handler backed by memory, holding a single goal of
+// xeffecttest.OWNER with no completed days.
+func newTestHandler(t *testing.T) (*Handler, xeffect.GoalRepository, string) {
+	t.Helper()
+
+	goals := xeffecttest.NewGoalRepository(t, xeffecttest.NewGoal("goal-1"))
+
+	return New(goals), goals, "goal-1"
+}
+
+func actionRequest(owner string, goalId string, body string) Request {
+	return Request{
+		HTTPMethod:            "POST",
+		Resource:              "/xeffect/goals/{goalId}",
+		PathParameters:        map[string]string{"goalId": goalId},
+		QueryStringParameters: map[string]string{"date": "2021-12-31"},
+		Headers:               map[string]string{"content-type": "application/json"},
+		RequestContext:        xeffecttest.RequestContext(owner),
+		Body:                  body,
+	}
+}
+
+// post makes an action request as xeffecttest.OWNER, failing the test unless
+// it is answered with status.
+func post(t *testing.T, handler *Handler, goalId string, body string, status int) Response {
+	t.Helper()
+
+	response, err := handler.HandleGoalActionEvent(context.Background(), actionRequest(xeffecttest.OWNER, goalId, body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != status {
+		t.Fatalf("%s was answered with %d, want %d: %s", body, response.StatusCode, status, response.Body)
+	}
+
+	return response
+}
+
+func mark(t *testing.T, handler *Handler, goalId string, date string, completed bool) Goal {
+	t.Helper()
+
+	action, err := json.Marshal(map[string]interface{}{
+		"action":       "mark_completed",
+		"date":         date,
+		"is_completed": completed,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return decodeGoal(t, post(t, handler, goalId, string(action), 200))
+}
+
+func decodeGoal(t *testing.T, response Response) Goal {
+	t.Helper()
+
+	var goal Goal
+	if err := json.Unmarshal([]byte(response.Body), &goal); err != nil {
+		t.Fatal(err)
+	}
+
+	return goal
+}
+
+// checkStreaks fails the test unless the goal has exactly the given streaks,
+// keyed by start date, and the stored goal matches it.
+func checkStreaks(t *testing.T, goals xeffect.GoalRepository, goal Goal, want map[string]int, wantDates []string) {
+	t.Helper()
+
+	got := map[string]int{}
+	for date, streak := range goal.Streaks {
+		got[date] = streak.Length
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("the streaks are %v, want %v", got, want)
+	}
+
+	if !reflect.DeepEqual(goal.StreakDates, wantDates) {
+		t.Errorf("the streak dates are %v, want %v", goal.StreakDates, wantDates)
+	}
+
+	stored, err := goals.GetGoal(context.Background(), goal.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(stored.Streaks, goal.Streaks) || !reflect.DeepEqual(stored.StreakDates, goal.StreakDates) {
+		t.Errorf("the stored streaks %v %v do not match those returned", stored.Streaks, stored.StreakDates)
+	}
+
+	if problems := xeffect.CheckStreaks(stored); len(problems) > 0 {
+		t.Errorf("the stored streaks are inconsistent: %v", problems)
+	}
+}
+
+func TestMarkCompleted(t *testing.T) {
+	handler, goals, goalId := newTestHandler(t)
+
+	goal := mark(t, handler, goalId, "2021-12-01", true)
+	checkStreaks(t, goals, goal, map[string]int{"2021-12-01": 1}, []string{"2021-12-01"})
+
+	goal = mark(t, handler, goalId, "2021-12-02", true)
+	checkStreaks(t, goals, goal, map[string]int{"2021-12-01": 2}, []string{"2021-12-01"})
+
+	goal = mark(t, handler, goalId, "2021-12-04", true)
+	checkStreaks(t, goals, goal, map[string]int{"2021-12-01": 2, "2021-12-04": 1}, []string{"2021-12-04", "2021-12-01"})
+
+	// Filling the gap merges the two streaks.
+	goal = mark(t, handler, goalId, "2021-12-03", true)
+	checkStreaks(t, goals, goal, map[string]int{"2021-12-01": 4}, []string{"2021-12-01"})
+
+	if goal.BestStreak != 4 {
+		t.Errorf("the best streak is %d, want 4", goal.BestStreak)
+	}
+
+	// Marking a day which is already completed changes nothing.
+	goal = mark(t, handler, goalId, "2021-12-02", true)
+	checkStreaks(t, goals, goal, map[string]int{"2021-12-01": 4}, []string{"2021-12-01"})
+
+	// Un-marking a day in the middle splits the streak around it.
+	goal = mark(t, handler, goalId, "2021-12-02", false)
+	checkStreaks(t, goals, goal, map[string]int{"2021-12-01": 1, "2021-12-03": 2}, []string{"2021-12-03", "2021-12-01"})
+
+	if goal.BestStreak != 2 {
+		t.Errorf("the best streak is %d, want 2", goal.BestStreak)
+	}
+
+	// Un-marking the first and last days of streaks shortens them.
+	goal = mark(t, handler, goalId, "2021-12-04", false)
+	checkStreaks(t, goals, goal, map[string]int{"2021-12-01": 1, "2021-12-03": 1}, []string{"2021-12-03", "2021-12-01"})
+
+	goal = mark(t, handler, goalId, "2021-12-01", false)
+	checkStreaks(t, goals, goal, map[string]int{"2021-12-03": 1}, []string{"2021-12-03"})
+
+	// Marking the day before the oldest streak extends it backwards.
+	goal = mark(t, handler, goalId, "2021-12-02", true)
+	checkStreaks(t, goals, goal, map[string]int{"2021-12-02": 2}, []string{"2021-12-02"})
+
+	if goal.Summary == nil || goal.Summary.TotalCompleted != 2 {
+		t.Errorf("the summary is %+v, want 2 days completed", goal.Summary)
+	}
+}
+
+func TestMarkCompletedBulk(t *testing.T) {
+	handler, goals, goalId := newTestHandler(t)
+
+	mark(t, handler, goalId, "2021-12-10", true)
+
+	goal := decodeGoal(t, post(t, handler, goalId, `{"action": "mark_completed_bulk", "from": "2021-12-05", "to": "2021-12-09", "is_completed": true}`, 200))
+	checkStreaks(t, goals, goal, map[string]int{"2021-12-05": 6}, []string{"2021-12-05"})
+
+	goal = decodeGoal(t, post(t, handler, goalId, `{"action": "mark_completed_bulk", "dates": ["2021-12-06", "2021-12-08"], "is_completed": false}`, 200))
+	checkStreaks(t, goals, goal, map[string]int{"2021-12-05": 1, "2021-12-07": 1, "2021-12-09": 2}, []string{"2021-12-09", "2021-12-07", "2021-12-05"})
+}
+
+func TestUndo(t *testing.T) {
+	handler, goals, goalId := newTestHandler(t)
+
+	post(t, handler, goalId, `{"action": "undo"}`, 400)
+
+	mark(t, handler, goalId, "2021-12-01", true)
+	mark(t, handler, goalId, "2021-12-02", true)
+	goal := mark(t, handler, goalId, "2021-12-01", false)
+	checkStreaks(t, goals, goal, map[string]int{"2021-12-02": 1}, []string{"2021-12-02"})
+
+	goal = decodeGoal(t, post(t, handler, goalId, `{"action": "undo"}`, 200))
+	checkStreaks(t, goals, goal, map[string]int{"2021-12-01": 2}, []string{"2021-12-01"})
+
+	post(t, handler, goalId, `{"action": "undo"}`, 200)
+	goal = decodeGoal(t, post(t, handler, goalId, `{"action": "undo"}`, 200))
+	checkStreaks(t, goals, goal, map[string]int{}, []string{})
+
+	var body xeffect.ErrorResponse
+	response := post(t, handler, goalId, `{"action": "undo"}`, 400)
+	if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	if body.Code != "nothing_to_undo" {
+		t.Errorf("the error code is '%s', want 'nothing_to_undo'", body.Code)
+	}
+
+	// Every change, including those undone, is recorded as an event.
+	page, err := goals.ListCompletionEvents(context.Background(), goalId, xeffect.CompletionEventQuery{PageSize: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page.Events) != 6 {
+		t.Errorf("%d events were recorded, want 6", len(page.Events))
+	}
+}
+
+func TestUnknownGoal(t *testing.T) {
+	handler, _, goalId := newTestHandler(t)
+
+	body := `{"action": "mark_completed", "date": "2021-12-01", "is_completed": true}`
+	post(t, handler, "goal-2", body, 404)
+	post(t, handler, "goal-2", `{"action": "undo"}`, 404)
+
+	// The goal of another owner is treated as if it does not exist.
+	response, err := handler.HandleGoalActionEvent(context.Background(), actionRequest("owner-2", goalId, body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != 404 {
+		t.Errorf("another owner's goal was answered with %d, want 404", response.StatusCode)
+	}
+}
+
+func TestUnsupportedAction(t *testing.T) {
+	handler, _, goalId := newTestHandler(t)
+
+	var body xeffect.ErrorResponse
+	response := post(t, handler, goalId, `{"action": "delete"}`, 400)
+	if err := json.Unmarshal([]byte(response.Body), &body); err != nil {
+		t.Fatal(err)
+	}
+
+	if body.Code != "unsupported_action" {
+		t.Errorf("the error code is '%s', want 'unsupported_action'", body.Code)
+	}
+
+	post(t, handler, goalId, `{"date": "2021-12-01"}`, 400)
+	post(t, handler, goalId, `{"action": "mark_completed", "date": "2021-12-01"}`, 400)
+	post(t, handler, goalId, `{"action": "mark_completed", "date": "1 Dec", "is_completed": true}`, 400)
+}
+
+func TestUnauthorized(t *testing.T) {
+	handler, _, goalId := newTestHandler(t)
+
+	response, err := handler.HandleGoalActionEvent(context.Background(), actionRequest("", goalId, `{"action": "undo"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != 401 {
+		t.Errorf("a request without an owner was answered with %d, want 401", response.StatusCode)
+	}
+}
+
+func TestArchive(t *testing.T) {
+	handler, goals, goalId := newTestHandler(t)
+
+	goal := decodeGoal(t, post(t, handler, goalId, `{"action": "archive"}`, 200))
+	if !goal.Archived {
+		t.Error("the goal was not archived")
+	}
+
+	mark(t, handler, goalId, "2021-12-01", true)
+
+	goal = decodeGoal(t, post(t, handler, goalId, `{"action": "restore"}`, 200))
+	if goal.Archived {
+		t.Error("the goal was not restored")
+	}
+
+	stored, err := goals.GetGoal(context.Background(), goalId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.Archived || stored.Version != 3 {
+		t.Errorf("the stored goal is archived %v at version %d, want restored at version 3", stored.Archived, stored.Version)
+	}
+}
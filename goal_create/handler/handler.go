@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+type Request events.APIGatewayProxyRequest
+type Response events.APIGatewayProxyResponse
+
+// Handler serves goal_create requests using the goals held in a GoalRepository.
+type Handler struct {
+	goals xeffect.GoalRepository
+}
+
+// New returns a Handler backed by goals.
+func New(goals xeffect.GoalRepository) *Handler {
+	return &Handler{goals: goals}
+}
+
+// NewGoal is the payload of a new goal. A goal without a schedule is to be
+// completed every day, and a target is only set when it is given.
+type NewGoal struct {
+	Title      string            `json:"title" validate:"required"`
+	Motivation string            `json:"motivation" validate:"required"`
+	Schedule   *xeffect.Schedule `json:"schedule"`
+	Target     *xeffect.Target   `json:"target"`
+}
+
+func returnError(err error) (Response, error) {
+	return Response{
+		StatusCode:      400,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "text/plain",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: err.Error(),
+	}, nil
+}
+
+func returnUnauthorized() (Response, error) {
+	response, err := xeffect.UnauthorizedResponse()
+	return Response(response), err
+}
+
+func (h *Handler) HandleGoalCreationEvent(ctx context.Context, event Request) (Response, error) {
+	owner, ok := xeffect.RequestOwner(event.RequestContext)
+	if !ok {
+		return returnUnauthorized()
+	}
+
+	var body []byte
+	if event.IsBase64Encoded {
+		var err error
+		body, err = base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			return returnError(err)
+		}
+	} else {
+		body = []byte(event.Body)
+	}
+
+	contentType := event.Headers["content-type"]
+	if contentType != "application/json" {
+		headers, _ := json.Marshal(event.Headers)
+		return returnError(fmt.Errorf("'%s' is not a supported Content-Type.\n%s", contentType, string(headers)))
+	}
+
+	var goal NewGoal
+	if err := json.Unmarshal(body, &goal); err != nil {
+		return returnError(err)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(goal); err != nil {
+		return returnError(err)
+	}
+
+	createdAt := time.Now().UTC().Format("2006-01-02")
+
+	// A schedule repeating every so many days starts when the goal is created,
+	// unless it is given a start date.
+	if goal.Schedule != nil {
+		if goal.Schedule.EveryDays != 0 && goal.Schedule.StartDate == "" {
+			goal.Schedule.StartDate = createdAt
+		}
+
+		if err := goal.Schedule.Validate(); err != nil {
+			return returnError(err)
+		}
+	}
+
+	if goal.Target != nil {
+		if err := goal.Target.Validate(); err != nil {
+			return returnError(err)
+		}
+	}
+
+	err := h.goals.ForOwner(owner).CreateGoal(ctx, xeffect.Goal{
+		Uuid:        uuid.New().String(),
+		Title:       goal.Title,
+		Motivation:  goal.Motivation,
+		BestStreak:  0,
+		Streaks:     map[string]xeffect.GoalStreak{},
+		StreakDates: []string{},
+		CreatedAt:   createdAt,
+		Schedule:    goal.Schedule,
+		Target:      goal.Target,
+	})
+	if err != nil {
+		return returnError(err)
+	}
+
+	return Response{
+		StatusCode: 201,
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin": "*",
+		},
+	}, nil
+}
@@ -2,117 +2,38 @@ package main
 
 import (
 	"context"
-	"encoding/base64"
-	"encoding/json"
-	"fmt"
+	"log"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
-	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
-	"github.com/go-playground/validator/v10"
-	"github.com/google/uuid"
+	"github.com/maxstanley/xeffect_backend/goal_create/handler"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+	"github.com/maxstanley/xeffect_backend/xeffect/auth"
 )
 
-type Request events.APIGatewayProxyRequest
-type Response events.APIGatewayProxyResponse
-
-const GOAL_TABLE = "xeffect_goals"
-
-type Goal struct {
-	Title      string `json:"title" validate:"required"`
-	Motivation string `json:"motivation" validate:"required"`
-}
-
-func returnError(err error) (Response, error) {
-	return Response{
-		StatusCode:      400,
-		IsBase64Encoded: false,
-		Headers: map[string]string{
-			"Content-Type":                "text/plain",
-			"Access-Control-Allow-Origin": "*",
-		},
-		Body: err.Error(),
-	}, nil
-}
-
-func handleGoalCreationEvent(ctx context.Context, event Request) (Response, error) {
-	var body []byte
-	if event.IsBase64Encoded {
-		var err error
-		body, err = base64.StdEncoding.DecodeString(event.Body)
-		if err != nil {
-			return returnError(err)
-		}
-	} else {
-		body = []byte(event.Body)
-	}
-
-	contentType := event.Headers["content-type"]
-	if contentType != "application/json" {
-		headers, _ := json.Marshal(event.Headers)
-		return returnError(fmt.Errorf("'%s' is not a supported Content-Type.\n%s", contentType, string(headers)))
-	}
-
-	var goal Goal
-	if err := json.Unmarshal(body, &goal); err != nil {
-		return returnError(err)
-	}
-
-	validate := validator.New()
-	if err := validate.Struct(goal); err != nil {
-		return returnError(err)
+func main() {
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion("eu-west-2"))
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion("eu-west-2"))
+	verifier, err := auth.NewVerifierFromEnv()
 	if err != nil {
-		return returnError(err)
+		log.Fatal(err)
 	}
 
 	client := dynamodb.NewFromConfig(cfg)
-	emptyList, _ := attributevalue.MarshalList([]string{})
-	emptyMap, _ := attributevalue.MarshalMap(map[string]interface{}{})
-
-	input := &dynamodb.PutItemInput{
-		Item: map[string]types.AttributeValue{
-			"uuid": &types.AttributeValueMemberS{
-				Value: uuid.New().String(),
-			},
-			"Title": &types.AttributeValueMemberS{
-				Value: goal.Title,
-			},
-			"Motivation": &types.AttributeValueMemberS{
-				Value: goal.Motivation,
-			},
-			"BestStreak": &types.AttributeValueMemberN{
-				Value: "0",
-			},
-			"StreakDates": &types.AttributeValueMemberL{
-				Value: emptyList,
-			},
-			"Streaks": &types.AttributeValueMemberM{
-				Value: emptyMap,
-			},
-		},
-		TableName: aws.String(GOAL_TABLE),
+	authenticator := &auth.Authenticator{
+		Verifier: verifier,
+		APIKeys:  xeffect.NewDynamoDBAPIKeyRepository(client),
 	}
 
-	_, err = client.PutItem(ctx, input)
-	if err != nil {
-		return returnError(err)
-	}
+	h := handler.New(xeffect.NewDynamoDBGoalRepository(client))
 
-	return Response{
-		StatusCode: 201,
-		Headers: map[string]string{
-			"Access-Control-Allow-Origin": "*",
-		},
-	}, nil
-}
-
-func main() {
-	lambda.Start(handleGoalCreationEvent)
+	lambda.Start(authenticator.Wrap(func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		response, err := h.HandleGoalCreationEvent(ctx, handler.Request(event))
+		return events.APIGatewayProxyResponse(response), err
+	}))
 }
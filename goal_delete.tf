@@ -0,0 +1,20 @@
+data "archive_file" "goal_delete" {
+  type = "zip"
+  source_file = "goal_delete/goal_delete"
+  output_path = "goal_delete/${var.lambda_zip_file}"
+}
+
+resource "aws_lambda_function" "goal_delete" {
+  function_name = "goal_delete"
+  filename = data.archive_file.goal_delete.output_path
+  handler = "goal_delete"
+  source_code_hash = data.archive_file.goal_delete.output_base64sha256
+  runtime = "go1.x"
+  memory_size = 128
+  timeout = 10
+  role = aws_iam_role.iam_for_lambda.arn
+
+  environment {
+    variables = local.auth_environment
+  }
+}
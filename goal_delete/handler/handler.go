@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+type Request events.APIGatewayProxyRequest
+type Response events.APIGatewayProxyResponse
+
+// Handler serves goal_delete requests using the goals held in a GoalRepository.
+type Handler struct {
+	goals xeffect.GoalRepository
+}
+
+// New returns a Handler backed by goals.
+func New(goals xeffect.GoalRepository) *Handler {
+	return &Handler{goals: goals}
+}
+
+func returnError(err error) (Response, error) {
+	return Response{
+		StatusCode:      400,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "text/plain",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: err.Error(),
+	}, nil
+}
+
+func returnErrorResponse(statusCode int, code string, message string) (Response, error) {
+	response, err := xeffect.JSONErrorResponse(statusCode, code, message)
+	return Response(response), err
+}
+
+func returnNotFound(goalId string) (Response, error) {
+	return returnErrorResponse(404, "goal_not_found", fmt.Sprintf("Goal '%s' does not exist.", goalId))
+}
+
+func returnUnauthorized() (Response, error) {
+	response, err := xeffect.UnauthorizedResponse()
+	return Response(response), err
+}
+
+func (h *Handler) HandleGoalDeleteEvent(ctx context.Context, event Request) (Response, error) {
+	owner, ok := xeffect.RequestOwner(event.RequestContext)
+	if !ok {
+		return returnUnauthorized()
+	}
+
+	goalId := event.PathParameters["goalId"]
+
+	err := h.goals.ForOwner(owner).DeleteGoal(ctx, goalId)
+	if errors.Is(err, xeffect.ErrGoalNotFound) {
+		return returnNotFound(goalId)
+	}
+
+	if err != nil {
+		return returnError(err)
+	}
+
+	return Response{
+		StatusCode: 204,
+		Headers: map[string]string{
+			"Access-Control-Allow-Origin": "*",
+		},
+	}, nil
+}
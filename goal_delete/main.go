@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/maxstanley/xeffect_backend/goal_delete/handler"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+	"github.com/maxstanley/xeffect_backend/xeffect/auth"
+)
+
+func main() {
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion("eu-west-2"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	verifier, err := auth.NewVerifierFromEnv()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	client := dynamodb.NewFromConfig(cfg)
+	authenticator := &auth.Authenticator{
+		Verifier: verifier,
+		APIKeys:  xeffect.NewDynamoDBAPIKeyRepository(client),
+	}
+
+	h := handler.New(xeffect.NewDynamoDBGoalRepository(client))
+
+	lambda.Start(authenticator.Wrap(func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		response, err := h.HandleGoalDeleteEvent(ctx, handler.Request(event))
+		return events.APIGatewayProxyResponse(response), err
+	}))
+}
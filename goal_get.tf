@@ -13,4 +13,8 @@ resource "aws_lambda_function" "goal_get" {
   memory_size = 128
   timeout = 10
   role = aws_iam_role.iam_for_lambda.arn
+
+  environment {
+    variables = local.auth_environment
+  }
 }
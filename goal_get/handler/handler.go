@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+type Request events.APIGatewayProxyRequest
+type Response events.APIGatewayProxyResponse
+
+// Handler serves goal_get requests using the goals held in a GoalRepository.
+type Handler struct {
+	goals xeffect.GoalRepository
+}
+
+// New returns a Handler backed by goals.
+func New(goals xeffect.GoalRepository) *Handler {
+	return &Handler{goals: goals}
+}
+
+// Goal is a goal along with a summary of its streaks.
+type Goal struct {
+	xeffect.Goal
+	Summary *xeffect.GoalSummary `json:"summary"`
+}
+
+func returnError(err error) (Response, error) {
+	return Response{
+		StatusCode:      400,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "text/plain",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: err.Error(),
+	}, nil
+}
+
+func parseDate(date string) (time.Time, error) {
+	return time.Parse("2006-01-02", date)
+}
+
+func returnErrorResponse(statusCode int, code string, message string) (Response, error) {
+	response, err := xeffect.JSONErrorResponse(statusCode, code, message)
+	return Response(response), err
+}
+
+func returnNotFound(goalId string) (Response, error) {
+	return returnErrorResponse(404, "goal_not_found", fmt.Sprintf("Goal '%s' does not exist.", goalId))
+}
+
+func returnUnauthorized() (Response, error) {
+	response, err := xeffect.UnauthorizedResponse()
+	return Response(response), err
+}
+
+func (h *Handler) HandleGoalGetEvent(ctx context.Context, event Request) (Response, error) {
+	owner, ok := xeffect.RequestOwner(event.RequestContext)
+	if !ok {
+		return returnUnauthorized()
+	}
+
+	goalId := event.PathParameters["goalId"]
+	date := event.QueryStringParameters["date"]
+	if date == "" {
+		date = time.Now().UTC().Format("2006-01-02")
+	}
+
+	if _, err := parseDate(date); err != nil {
+		return returnError(err)
+	}
+
+	stored, err := h.goals.ForOwner(owner).GetGoal(ctx, goalId)
+	if errors.Is(err, xeffect.ErrGoalNotFound) {
+		return returnNotFound(goalId)
+	}
+
+	if err != nil {
+		return returnError(err)
+	}
+
+	goal := Goal{
+		Goal: stored,
+	}
+
+	summary, err := xeffect.SummariseGoal(goal.Goal, date)
+	if err != nil {
+		return returnError(err)
+	}
+	goal.Summary = &summary
+
+	body, err := json.Marshal(goal)
+	if err != nil {
+		return returnError(err)
+	}
+
+	return Response{
+		StatusCode:      200,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(body),
+	}, nil
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+type Request events.APIGatewayProxyRequest
+type Response events.APIGatewayProxyResponse
+
+const (
+	DEFAULT_PAGE_SIZE = 25
+	MAX_PAGE_SIZE     = 100
+)
+
+// Handler serves goal_get_all requests using the goals held in a GoalRepository.
+type Handler struct {
+	goals xeffect.GoalRepository
+}
+
+// New returns a Handler backed by goals.
+func New(goals xeffect.GoalRepository) *Handler {
+	return &Handler{goals: goals}
+}
+
+// Goal is a goal along with a summary of its streaks.
+type Goal struct {
+	xeffect.Goal
+	Summary *xeffect.GoalSummary `json:"summary"`
+}
+
+// GoalsPage is a single page of goals. NextPageToken is only set when there are
+// more goals to be listed, and is passed as the page_token of the next request.
+type GoalsPage struct {
+	Goals         []Goal `json:"goals"`
+	NextPageToken string `json:"next_page_token,omitempty"`
+}
+
+func returnError(err error) (Response, error) {
+	return Response{
+		StatusCode:      400,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "text/plain",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: err.Error(),
+	}, nil
+}
+
+func returnUnauthorized() (Response, error) {
+	response, err := xeffect.UnauthorizedResponse()
+	return Response(response), err
+}
+
+func parseDate(date string) (time.Time, error) {
+	return time.Parse("2006-01-02", date)
+}
+
+func (h *Handler) HandleGoalGetAllEvent(ctx context.Context, event Request) (Response, error) {
+	owner, ok := xeffect.RequestOwner(event.RequestContext)
+	if !ok {
+		return returnUnauthorized()
+	}
+
+	date := event.QueryStringParameters["date"]
+	if date == "" {
+		date = time.Now().UTC().Format("2006-01-02")
+	}
+
+	if _, err := parseDate(date); err != nil {
+		return returnError(err)
+	}
+
+	includeArchived := false
+	if value, ok := event.QueryStringParameters["include_archived"]; ok {
+		var err error
+		includeArchived, err = strconv.ParseBool(value)
+		if err != nil {
+			return returnError(err)
+		}
+	}
+
+	pageSize := DEFAULT_PAGE_SIZE
+	if value, ok := event.QueryStringParameters["page_size"]; ok {
+		var err error
+		pageSize, err = strconv.Atoi(value)
+		if err != nil {
+			return returnError(err)
+		}
+
+		if pageSize < 1 || pageSize > MAX_PAGE_SIZE {
+			return returnError(fmt.Errorf("page_size must be between 1 and %d", MAX_PAGE_SIZE))
+		}
+	}
+
+	page, err := h.goals.ForOwner(owner).ListGoals(ctx, xeffect.GoalQuery{
+		IncludeArchived: includeArchived,
+		PageSize:        pageSize,
+		PageToken:       event.QueryStringParameters["page_token"],
+	})
+	if errors.Is(err, xeffect.ErrInvalidPageToken) {
+		return returnError(fmt.Errorf("'%s' is not a valid page token", event.QueryStringParameters["page_token"]))
+	}
+
+	if err != nil {
+		return returnError(err)
+	}
+
+	goals := make([]Goal, len(page.Goals))
+	for i := range page.Goals {
+		goals[i].Goal = page.Goals[i]
+	}
+
+	for i := range goals {
+		summary, err := xeffect.SummariseGoal(goals[i].Goal, date)
+		if err != nil {
+			return returnError(err)
+		}
+
+		goals[i].Summary = &summary
+	}
+
+	response := GoalsPage{
+		Goals:         goals,
+		NextPageToken: page.NextPageToken,
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		return returnError(err)
+	}
+
+	return Response{
+		StatusCode:      200,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(body),
+	}, nil
+}
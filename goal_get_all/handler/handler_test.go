@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/maxstanley/xeffect_backend/xeffect/xeffecttest"
+)
+
+// newTestHandler returns a handler backed by memory, holding goals goals of
+// xeffecttest.OWNER and archived archived goals, along with a goal of another
+// owner.
+func newTestHandler(t *testing.T, goals int, archived int) *Handler {
+	t.Helper()
+
+	repository := xeffecttest.NewGoalRepository(t)
+	for i := 0; i < goals; i++ {
+		xeffecttest.CreateGoals(t, repository, xeffecttest.OWNER, xeffecttest.NewGoal(fmt.Sprintf("goal-%03d", i)))
+	}
+	for i := 0; i < archived; i++ {
+		goal := xeffecttest.NewGoal(fmt.Sprintf("archived-%03d", i))
+		goal.Archived = true
+		xeffecttest.CreateGoals(t, repository, xeffecttest.OWNER, goal)
+	}
+	xeffecttest.CreateGoals(t, repository, "owner-2", xeffecttest.NewGoal("other-goal"))
+
+	return New(repository)
+}
+
+// list requests a page of goals as xeffecttest.OWNER, failing the test unless
+// it is answered with status.
+func list(t *testing.T, handler *Handler, parameters map[string]string, status int) GoalsPage {
+	t.Helper()
+
+	parameters["date"] = "2021-12-31"
+	response, err := handler.HandleGoalGetAllEvent(context.Background(), Request{
+		HTTPMethod:            "GET",
+		QueryStringParameters: parameters,
+		RequestContext:        xeffecttest.RequestContext(xeffecttest.OWNER),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != status {
+		t.Fatalf("%v was answered with %d, want %d: %s", parameters, response.StatusCode, status, response.Body)
+	}
+
+	var page GoalsPage
+	if status == 200 {
+		if err := json.Unmarshal([]byte(response.Body), &page); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return page
+}
+
+func TestPageSize(t *testing.T) {
+	handler := newTestHandler(t, MAX_PAGE_SIZE+5, 0)
+
+	tests := []struct {
+		pageSize string
+		status   int
+		goals    int
+	}{
+		{pageSize: "", status: 200, goals: DEFAULT_PAGE_SIZE},
+		{pageSize: "1", status: 200, goals: 1},
+		{pageSize: fmt.Sprint(MAX_PAGE_SIZE), status: 200, goals: MAX_PAGE_SIZE},
+		{pageSize: "0", status: 400},
+		{pageSize: "-1", status: 400},
+		{pageSize: fmt.Sprint(MAX_PAGE_SIZE + 1), status: 400},
+		{pageSize: "ten", status: 400},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("page_size=%s", test.pageSize), func(t *testing.T) {
+			parameters := map[string]string{}
+			if test.pageSize != "" {
+				parameters["page_size"] = test.pageSize
+			}
+
+			page := list(t, handler, parameters, test.status)
+			if test.status != 200 {
+				return
+			}
+
+			if len(page.Goals) != test.goals {
+				t.Errorf("%d goals were listed, want %d", len(page.Goals), test.goals)
+			}
+
+			if page.NextPageToken == "" {
+				t.Error("a page which does not hold every goal has no next page token")
+			}
+		})
+	}
+}
+
+func TestPageTokens(t *testing.T) {
+	handler := newTestHandler(t, 30, 3)
+
+	tests := []struct {
+		includeArchived string
+		goals           int
+	}{
+		{includeArchived: "false", goals: 30},
+		{includeArchived: "true", goals: 33},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("include_archived=%s", test.includeArchived), func(t *testing.T) {
+			seen := map[string]bool{}
+
+			parameters := map[string]string{"page_size": "7", "include_archived": test.includeArchived}
+			for pages := 1; ; pages++ {
+				if pages > test.goals {
+					t.Fatal("the pages of goals never end")
+				}
+
+				page := list(t, handler, parameters, 200)
+				if len(page.Goals) > 7 {
+					t.Errorf("page %d lists %d goals, want at most 7", pages, len(page.Goals))
+				}
+
+				for _, goal := range page.Goals {
+					if seen[goal.Uuid] {
+						t.Errorf("goal %s is listed more than once", goal.Uuid)
+					}
+					seen[goal.Uuid] = true
+
+					if goal.Summary == nil {
+						t.Errorf("goal %s is listed without a summary", goal.Uuid)
+					}
+				}
+
+				if page.NextPageToken == "" {
+					break
+				}
+				parameters["page_token"] = page.NextPageToken
+			}
+
+			if len(seen) != test.goals {
+				t.Errorf("%d goals were listed, want %d", len(seen), test.goals)
+			}
+
+			if seen["other-goal"] {
+				t.Error("the goal of another owner was listed")
+			}
+		})
+	}
+
+	list(t, handler, map[string]string{"page_token": "not-a-token"}, 400)
+}
@@ -2,83 +2,38 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"log"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/maxstanley/xeffect_backend/goal_get_all/handler"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+	"github.com/maxstanley/xeffect_backend/xeffect/auth"
 )
 
-type Request events.APIGatewayProxyRequest
-type Response events.APIGatewayProxyResponse
-
-var GOAL_TABLE = "xeffect_goals"
-
-type Goal struct {
-	Uuid        string                `json:"uuid"`
-	Title       string                `json:"title" validate:"required"`
-	Motivation  string                `json:"motivation" validate:"required"`
-	BestStreak  int                   `json:"best_streak"`
-	Streaks     map[string]GoalStreak `json:"streaks"`
-	StreakDates []string              `json:"streak_dates"`
-}
-
-type GoalStreak struct {
-	Length  int               `json:"streak_length"`
-	Partial map[string]string `json:"partial"`
-}
-
-func returnError(err error) (Response, error) {
-	return Response{
-		StatusCode:      400,
-		IsBase64Encoded: false,
-		Headers: map[string]string{
-			"Content-Type":                "text/plain",
-			"Access-Control-Allow-Origin": "*",
-		},
-		Body: err.Error(),
-	}, nil
-}
-
-func handleGoalGetAllEvent(ctx context.Context, event Request) (Response, error) {
-	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion("eu-west-2"))
+func main() {
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion("eu-west-2"))
 	if err != nil {
-		return returnError(err)
+		log.Fatal(err)
 	}
 
-	input := &dynamodb.ScanInput{
-		TableName: &GOAL_TABLE,
-	}
-
-	client := dynamodb.NewFromConfig(cfg)
-	result, err := client.Scan(ctx, input)
+	verifier, err := auth.NewVerifierFromEnv()
 	if err != nil {
-		return returnError(err)
-	}
-
-	goals := []Goal{}
-	if err := attributevalue.UnmarshalListOfMaps(result.Items, &goals); err != nil {
-		return returnError(err)
+		log.Fatal(err)
 	}
 
-	body, err := json.Marshal(goals)
-	if err != nil {
-		return returnError(err)
+	client := dynamodb.NewFromConfig(cfg)
+	authenticator := &auth.Authenticator{
+		Verifier: verifier,
+		APIKeys:  xeffect.NewDynamoDBAPIKeyRepository(client),
 	}
 
-	return Response{
-		StatusCode:      200,
-		IsBase64Encoded: false,
-		Headers: map[string]string{
-			"Content-Type":                "application/json",
-			"Access-Control-Allow-Origin": "*",
-		},
-		Body: string(body),
-	}, nil
-}
+	h := handler.New(xeffect.NewDynamoDBGoalRepository(client))
 
-func main() {
-	lambda.Start(handleGoalGetAllEvent)
+	lambda.Start(authenticator.Wrap(func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		response, err := h.HandleGoalGetAllEvent(ctx, handler.Request(event))
+		return events.APIGatewayProxyResponse(response), err
+	}))
 }
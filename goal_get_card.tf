@@ -0,0 +1,20 @@
+data "archive_file" "goal_get_card" {
+  type = "zip"
+  source_file = "goal_get_card/goal_get_card"
+  output_path = "goal_get_card/${var.lambda_zip_file}"
+}
+
+resource "aws_lambda_function" "goal_get_card" {
+  function_name = "goal_get_card"
+  filename = data.archive_file.goal_get_card.output_path
+  handler = "goal_get_card"
+  source_code_hash = data.archive_file.goal_get_card.output_base64sha256
+  runtime = "go1.x"
+  memory_size = 128
+  timeout = 10
+  role = aws_iam_role.iam_for_lambda.arn
+
+  environment {
+    variables = local.auth_environment
+  }
+}
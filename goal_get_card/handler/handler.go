@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+type Request events.APIGatewayProxyRequest
+type Response events.APIGatewayProxyResponse
+
+// The resources served besides the current card. The history lists every card
+// of a goal, and the current card is also rendered as an SVG or PNG image.
+const (
+	CARD_HISTORY_RESOURCE = "/xeffect/goals/{goalId}/cards"
+	CARD_SVG_RESOURCE     = "/xeffect/goals/{goalId}/card.svg"
+	CARD_PNG_RESOURCE     = "/xeffect/goals/{goalId}/card.png"
+)
+
+// Handler serves goal_get_card requests using the goals held in a
+// GoalRepository.
+type Handler struct {
+	goals xeffect.GoalRepository
+}
+
+// New returns a Handler backed by goals.
+func New(goals xeffect.GoalRepository) *Handler {
+	return &Handler{goals: goals}
+}
+
+// Cards is the history of the cards of a goal, oldest first, without their
+// days.
+type Cards struct {
+	Cards []xeffect.Card `json:"cards"`
+}
+
+func returnError(err error) (Response, error) {
+	return Response{
+		StatusCode:      400,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "text/plain",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: err.Error(),
+	}, nil
+}
+
+func returnErrorResponse(statusCode int, code string, message string) (Response, error) {
+	response, err := xeffect.JSONErrorResponse(statusCode, code, message)
+	return Response(response), err
+}
+
+func returnNotFound(goalId string) (Response, error) {
+	return returnErrorResponse(404, "goal_not_found", fmt.Sprintf("Goal '%s' does not exist.", goalId))
+}
+
+func returnUnauthorized() (Response, error) {
+	response, err := xeffect.UnauthorizedResponse()
+	return Response(response), err
+}
+
+func parseDate(date string) (time.Time, error) {
+	return time.Parse("2006-01-02", date)
+}
+
+// HandleGoalGetCardEvent returns the current card of a goal, along with its
+// days, or every card of the goal when the card history is requested. The
+// current card is drawn as an image when one is requested, with the date
+// highlighted.
+func (h *Handler) HandleGoalGetCardEvent(ctx context.Context, event Request) (Response, error) {
+	owner, ok := xeffect.RequestOwner(event.RequestContext)
+	if !ok {
+		return returnUnauthorized()
+	}
+
+	goalId := event.PathParameters["goalId"]
+	date := event.QueryStringParameters["date"]
+	if date == "" {
+		date = time.Now().UTC().Format("2006-01-02")
+	}
+
+	if _, err := parseDate(date); err != nil {
+		return returnError(err)
+	}
+
+	goal, err := h.goals.ForOwner(owner).GetGoal(ctx, goalId)
+	if errors.Is(err, xeffect.ErrGoalNotFound) {
+		return returnNotFound(goalId)
+	}
+
+	if err != nil {
+		return returnError(err)
+	}
+
+	cards, err := xeffect.GoalCards(goal, date)
+	if err != nil {
+		return returnError(err)
+	}
+
+	switch event.Resource {
+	case CARD_SVG_RESOURCE:
+		return Response{
+			StatusCode:      200,
+			IsBase64Encoded: false,
+			Headers: map[string]string{
+				"Content-Type":                "image/svg+xml",
+				"Access-Control-Allow-Origin": "*",
+			},
+			Body: string(renderSVG(newCardImage(goal, cards[len(cards)-1], date))),
+		}, nil
+	case CARD_PNG_RESOURCE:
+		image, err := renderPNG(newCardImage(goal, cards[len(cards)-1], date))
+		if err != nil {
+			return returnError(err)
+		}
+
+		return Response{
+			StatusCode:      200,
+			IsBase64Encoded: true,
+			Headers: map[string]string{
+				"Content-Type":                "image/png",
+				"Access-Control-Allow-Origin": "*",
+			},
+			Body: base64.StdEncoding.EncodeToString(image),
+		}, nil
+	}
+
+	var response interface{}
+	if event.Resource == CARD_HISTORY_RESOURCE {
+		for i := range cards {
+			cards[i].Days = nil
+		}
+		response = Cards{
+			Cards: cards,
+		}
+	} else {
+		response = cards[len(cards)-1]
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		return returnError(err)
+	}
+
+	return Response{
+		StatusCode:      200,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(body),
+	}, nil
+}
@@ -0,0 +1,399 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/maxstanley/xeffect_backend/xeffect"
+	"github.com/maxstanley/xeffect_backend/xeffect/xeffecttest"
+)
+
+const CARD_RESOURCE = "/xeffect/goals/{goalId}/card"
+
+// completedBetween returns every date from from to to inclusive.
+func completedBetween(t *testing.T, from string, to string) []string {
+	t.Helper()
+
+	start, err := parseDate(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	end, err := parseDate(to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dates := []string{}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		dates = append(dates, day.Format("2006-01-02"))
+	}
+
+	return dates
+}
+
+// newTestHandler returns a handler backed by memory, holding a single daily
+// goal of xeffecttest.OWNER created on createdAt and completed on the given
+// dates.
+func newTestHandler(t *testing.T, title string, createdAt string, completed []string) (*Handler, string) {
+	t.Helper()
+
+	days := map[string]bool{}
+	for _, date := range completed {
+		days[date] = true
+	}
+
+	streaks, streakDates, err := xeffect.StreaksFromDays(days, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	goal := xeffecttest.NewGoal("goal-1")
+	goal.Title = title
+	goal.Streaks = streaks
+	goal.StreakDates = streakDates
+	goal.CreatedAt = createdAt
+
+	return New(xeffecttest.NewGoalRepository(t, goal)), goal.Uuid
+}
+
+// get requests resource of a goal as of date, failing the test unless it is
+// answered with status.
+func get(t *testing.T, handler *Handler, resource string, goalId string, date string, status int) Response {
+	t.Helper()
+
+	response, err := handler.HandleGoalGetCardEvent(context.Background(), Request{
+		HTTPMethod:            "GET",
+		Resource:              resource,
+		PathParameters:        map[string]string{"goalId": goalId},
+		QueryStringParameters: map[string]string{"date": date},
+		RequestContext:        xeffecttest.RequestContext(xeffecttest.OWNER),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != status {
+		t.Fatalf("%s as of %s was answered with %d, want %d: %s", resource, date, response.StatusCode, status, response.Body)
+	}
+
+	return response
+}
+
+func TestCurrentCard(t *testing.T) {
+	tests := []struct {
+		name      string
+		createdAt string
+		completed []string
+		date      string
+		// The current card, and how many cards there are in all.
+		number        int
+		startDate     string
+		endDate       string
+		status        string
+		completedDays int
+		cards         int
+	}{
+		{
+			name:          "fewer days of history than a card",
+			createdAt:     "2021-12-01",
+			completed:     completedBetween(t, "2021-12-01", "2021-12-03"),
+			date:          "2021-12-03",
+			number:        1,
+			startDate:     "2021-12-01",
+			endDate:       "2022-01-18",
+			status:        xeffect.CARD_IN_PROGRESS,
+			completedDays: 3,
+			cards:         1,
+		},
+		{
+			name:          "no days completed",
+			createdAt:     "2021-12-01",
+			completed:     []string{},
+			date:          "2021-12-01",
+			number:        1,
+			startDate:     "2021-12-01",
+			endDate:       "2022-01-18",
+			status:        xeffect.CARD_IN_PROGRESS,
+			completedDays: 0,
+			cards:         1,
+		},
+		{
+			name:          "completed before the goal was created",
+			createdAt:     "2021-12-05",
+			completed:     []string{"2021-12-03"},
+			date:          "2021-12-03",
+			number:        1,
+			startDate:     "2021-12-03",
+			endDate:       "2022-01-20",
+			status:        xeffect.CARD_IN_PROGRESS,
+			completedDays: 1,
+			cards:         1,
+		},
+		{
+			name:          "across the end of a month",
+			createdAt:     "2021-11-20",
+			completed:     completedBetween(t, "2021-11-20", "2021-12-10"),
+			date:          "2021-12-10",
+			number:        1,
+			startDate:     "2021-11-20",
+			endDate:       "2022-01-07",
+			status:        xeffect.CARD_IN_PROGRESS,
+			completedDays: 21,
+			cards:         1,
+		},
+		{
+			name:          "the next card after a completed card, across the end of a year",
+			createdAt:     "2021-12-01",
+			completed:     completedBetween(t, "2021-12-01", "2022-01-20"),
+			date:          "2022-01-20",
+			number:        2,
+			startDate:     "2022-01-19",
+			endDate:       "2022-03-08",
+			status:        xeffect.CARD_IN_PROGRESS,
+			completedDays: 2,
+			cards:         2,
+		},
+		{
+			name:          "the next card after a failed card, across the end of a year",
+			createdAt:     "2021-12-28",
+			completed:     []string{"2021-12-28", "2021-12-29", "2022-01-02"},
+			date:          "2022-01-03",
+			number:        2,
+			startDate:     "2022-01-02",
+			endDate:       "2022-02-19",
+			status:        xeffect.CARD_IN_PROGRESS,
+			completedDays: 1,
+			cards:         2,
+		},
+		{
+			name:          "a missed day",
+			createdAt:     "2021-12-28",
+			completed:     []string{"2021-12-28", "2021-12-29"},
+			date:          "2022-01-03",
+			number:        2,
+			startDate:     "2022-01-03",
+			endDate:       "2022-02-20",
+			status:        xeffect.CARD_IN_PROGRESS,
+			completedDays: 0,
+			cards:         2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler, goalId := newTestHandler(t, "Read", test.createdAt, test.completed)
+
+			var card xeffect.Card
+			if err := json.Unmarshal([]byte(get(t, handler, CARD_RESOURCE, goalId, test.date, 200).Body), &card); err != nil {
+				t.Fatal(err)
+			}
+
+			if card.Number != test.number || card.StartDate != test.startDate || card.EndDate != test.endDate || card.Status != test.status || card.CompletedDays != test.completedDays {
+				t.Errorf("the current card is %d from %s to %s, %s with %d days completed, want %d from %s to %s, %s with %d days completed",
+					card.Number, card.StartDate, card.EndDate, card.Status, card.CompletedDays,
+					test.number, test.startDate, test.endDate, test.status, test.completedDays)
+			}
+
+			// Every day of the card is listed in order, from its start date.
+			if len(card.Days) != xeffect.CARD_DAYS {
+				t.Fatalf("the card has %d days, want %d", len(card.Days), xeffect.CARD_DAYS)
+			}
+
+			start, err := parseDate(test.startDate)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			completed := map[string]bool{}
+			for _, date := range test.completed {
+				completed[date] = true
+			}
+
+			for i, day := range card.Days {
+				if want := start.AddDate(0, 0, i).Format("2006-01-02"); day.Date != want {
+					t.Fatalf("day %d of the card is %s, want %s", i+1, day.Date, want)
+				}
+
+				if (day.Status == xeffect.CARD_DAY_COMPLETED) != completed[day.Date] {
+					t.Errorf("%s is %s on the card", day.Date, day.Status)
+				}
+			}
+
+			var history Cards
+			if err := json.Unmarshal([]byte(get(t, handler, CARD_HISTORY_RESOURCE, goalId, test.date, 200).Body), &history); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(history.Cards) != test.cards {
+				t.Fatalf("the goal has %d cards, want %d", len(history.Cards), test.cards)
+			}
+
+			if last := history.Cards[len(history.Cards)-1]; last.StartDate != test.startDate || len(last.Days) != 0 {
+				t.Errorf("the last card of the history starts on %s with %d days, want %s without its days", last.StartDate, len(last.Days), test.startDate)
+			}
+		})
+	}
+}
+
+func TestCardDate(t *testing.T) {
+	handler, goalId := newTestHandler(t, "Read", "2021-12-01", []string{})
+
+	get(t, handler, CARD_RESOURCE, goalId, "1 Dec", 400)
+	get(t, handler, CARD_RESOURCE, "goal-2", "2021-12-01", 404)
+
+	// Without a date, the card is as of today.
+	response, err := handler.HandleGoalGetCardEvent(context.Background(), Request{
+		Resource:       CARD_RESOURCE,
+		PathParameters: map[string]string{"goalId": goalId},
+		RequestContext: xeffecttest.RequestContext(xeffecttest.OWNER),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var card xeffect.Card
+	if err := json.Unmarshal([]byte(response.Body), &card); err != nil {
+		t.Fatal(err)
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	if card.StartDate > today || card.EndDate < today {
+		t.Errorf("the card from %s to %s does not hold today, %s", card.StartDate, card.EndDate, today)
+	}
+}
+
+func TestCardImage(t *testing.T) {
+	// The goal is completed on the first two days of its card, which is drawn as
+	// of the third.
+	handler, goalId := newTestHandler(t, "Read <daily> & write", "2021-12-30", []string{"2021-12-30", "2021-12-31"})
+
+	tests := []struct {
+		resource    string
+		contentType string
+		base64      bool
+	}{
+		{resource: CARD_SVG_RESOURCE, contentType: "image/svg+xml"},
+		{resource: CARD_PNG_RESOURCE, contentType: "image/png", base64: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.resource, func(t *testing.T) {
+			response := get(t, handler, test.resource, goalId, "2022-01-01", 200)
+
+			if contentType := response.Headers["Content-Type"]; contentType != test.contentType {
+				t.Errorf("the Content-Type is '%s', want '%s'", contentType, test.contentType)
+			}
+
+			if response.IsBase64Encoded != test.base64 {
+				t.Errorf("the body is base64 encoded %v, want %v", response.IsBase64Encoded, test.base64)
+			}
+
+			switch test.resource {
+			case CARD_SVG_RESOURCE:
+				checkSVG(t, []byte(response.Body))
+			case CARD_PNG_RESOURCE:
+				body, err := base64.StdEncoding.DecodeString(response.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+				checkPNG(t, body)
+			}
+		})
+	}
+
+	get(t, handler, CARD_PNG_RESOURCE, "goal-2", "2022-01-01", 404)
+}
+
+// checkSVG checks that the card is well formed XML, with its title escaped.
+func checkSVG(t *testing.T, body []byte) {
+	t.Helper()
+
+	var svg struct {
+		XMLName xml.Name
+		Width   int      `xml:"width,attr"`
+		Height  int      `xml:"height,attr"`
+		Text    []string `xml:"g>text"`
+	}
+	if err := xml.Unmarshal(body, &svg); err != nil {
+		t.Fatalf("the card is not valid XML: %v", err)
+	}
+
+	if svg.XMLName.Local != "svg" || svg.Width != CARD_WIDTH || svg.Height != CARD_HEIGHT {
+		t.Errorf("the card is a %s of %dx%d, want an svg of %dx%d", svg.XMLName.Local, svg.Width, svg.Height, CARD_WIDTH, CARD_HEIGHT)
+	}
+
+	if len(svg.Text) == 0 || svg.Text[0] != "Read <daily> & write" {
+		t.Errorf("the text of the card is %q, want it to start with the title", svg.Text)
+	}
+
+	if !bytes.Contains(body, []byte(`fill="`+CARD_TODAY+`"`)) {
+		t.Error("today is not highlighted")
+	}
+}
+
+// checkPNG decodes the card, and checks the colour of the days drawn on it.
+func checkPNG(t *testing.T, body []byte) {
+	t.Helper()
+
+	img, err := png.Decode(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("the card is not a PNG image: %v", err)
+	}
+
+	if size := img.Bounds().Size(); size.X != CARD_WIDTH || size.Y != CARD_HEIGHT {
+		t.Errorf("the card is %dx%d, want %dx%d", size.X, size.Y, CARD_WIDTH, CARD_HEIGHT)
+	}
+
+	colours := []struct {
+		day    int
+		colour string
+	}{
+		// The centre of a completed day is crossed out.
+		{day: 0, colour: CARD_MARK},
+		{day: 1, colour: CARD_MARK},
+		{day: 2, colour: CARD_TODAY},
+		{day: 3, colour: CARD_BACKGROUND},
+	}
+
+	for _, test := range colours {
+		x, y := cell(test.day)
+		got := color.RGBAModel.Convert(img.At(x+CARD_CELL_SIZE/2, y+CARD_CELL_SIZE/2))
+
+		if want := hexColor(test.colour); got != want {
+			t.Errorf("day %d is drawn in %v, want %v", test.day+1, got, want)
+		}
+	}
+}
+
+func TestRenderPNGMissedDay(t *testing.T) {
+	// The current card never holds a missed day, as a card fails on the day it
+	// is missed and the next card starts after it, so one is drawn directly.
+	body, err := renderPNG(cardImage{
+		Days: []xeffect.CardDay{
+			{Date: "2021-12-01", Status: xeffect.CARD_DAY_COMPLETED},
+			{Date: "2021-12-02", Status: xeffect.CARD_DAY_MISSED},
+		},
+		Today: "2021-12-03",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("the card is not a PNG image: %v", err)
+	}
+
+	x, y := cell(1)
+	if got, want := color.RGBAModel.Convert(img.At(x+CARD_CELL_SIZE/2, y+CARD_CELL_SIZE/2)), hexColor(CARD_MISSED); got != want {
+		t.Errorf("the missed day is drawn in %v, want %v", got, want)
+	}
+}
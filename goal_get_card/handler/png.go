@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+
+	"github.com/maxstanley/xeffect_backend/xeffect"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// PNG_CHARACTER_WIDTH is the width of each character of the font text is drawn
+// with in a PNG image.
+const PNG_CHARACTER_WIDTH = 7
+
+// hexColor converts a colour in the form #rrggbb.
+func hexColor(hex string) color.RGBA {
+	value, _ := strconv.ParseUint(hex[1:], 16, 32)
+
+	return color.RGBA{
+		R: uint8(value >> 16),
+		G: uint8(value >> 8),
+		B: uint8(value),
+		A: 0xff,
+	}
+}
+
+func fillRect(img draw.Image, rect image.Rectangle, hex string) {
+	draw.Draw(img, rect, image.NewUniform(hexColor(hex)), image.Point{}, draw.Src)
+}
+
+// drawLine draws a straight line width pixels wide between two points.
+func drawLine(img draw.Image, x0 int, y0 int, x1 int, y1 int, width int, hex string) {
+	dx, dy := x1-x0, y1-y0
+	steps := dx
+	if steps < 0 {
+		steps = -steps
+	}
+	if dy > steps {
+		steps = dy
+	} else if -dy > steps {
+		steps = -dy
+	}
+
+	for i := 0; i <= steps; i++ {
+		x := x0 + dx*i/steps
+		y := y0 + dy*i/steps
+		fillRect(img, image.Rect(x-width/2, y-width/2, x-width/2+width, y-width/2+width), hex)
+	}
+}
+
+func drawText(img draw.Image, x int, y int, text string, hex string) {
+	drawer := &font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(hexColor(hex)),
+		Face: basicfont.Face7x13,
+		Dot:  fixed.P(x, y),
+	}
+	drawer.DrawString(text)
+}
+
+// renderPNG draws the card as a PNG image, laid out in the same way as the SVG
+// image.
+func renderPNG(card cardImage) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, CARD_WIDTH, CARD_HEIGHT))
+	fillRect(img, img.Bounds(), CARD_BACKGROUND)
+
+	maxCharacters := (CARD_WIDTH - 2*CARD_MARGIN) / PNG_CHARACTER_WIDTH
+	drawText(img, CARD_MARGIN, 40, truncate(card.Title, maxCharacters), CARD_TEXT)
+	drawText(img, CARD_MARGIN, 64, truncate(card.Motivation, maxCharacters), CARD_TEXT)
+	drawText(img, CARD_MARGIN, 86, card.Caption, CARD_MUTED_TEXT)
+	drawText(img, CARD_MARGIN, CARD_HEIGHT-12, card.Footer, CARD_MUTED_TEXT)
+
+	for i, day := range card.Days {
+		x, y := cell(i)
+
+		switch {
+		case day.Date == card.Today:
+			fillRect(img, image.Rect(x, y, x+CARD_CELL_SIZE, y+CARD_CELL_SIZE), CARD_TODAY)
+		case day.Status == xeffect.CARD_DAY_MISSED:
+			fillRect(img, image.Rect(x, y, x+CARD_CELL_SIZE, y+CARD_CELL_SIZE), CARD_MISSED)
+		}
+
+		if day.Status == xeffect.CARD_DAY_COMPLETED {
+			inset := CARD_CELL_SIZE / 5
+			drawLine(img, x+inset, y+inset, x+CARD_CELL_SIZE-inset, y+CARD_CELL_SIZE-inset, 4, CARD_MARK)
+			drawLine(img, x+CARD_CELL_SIZE-inset, y+inset, x+inset, y+CARD_CELL_SIZE-inset, 4, CARD_MARK)
+		}
+
+		if day.Status == xeffect.CARD_DAY_REST {
+			drawLine(img, x+2*CARD_CELL_SIZE/5, y+CARD_CELL_SIZE/2, x+3*CARD_CELL_SIZE/5, y+CARD_CELL_SIZE/2, 2, CARD_REST)
+		}
+	}
+
+	for i := 0; i <= 7; i++ {
+		offset := i * CARD_CELL_SIZE
+		fillRect(img, image.Rect(CARD_MARGIN+offset, CARD_HEADER, CARD_MARGIN+offset+1, CARD_HEADER+7*CARD_CELL_SIZE+1), CARD_GRID)
+		fillRect(img, image.Rect(CARD_MARGIN, CARD_HEADER+offset, CARD_MARGIN+7*CARD_CELL_SIZE+1, CARD_HEADER+offset+1), CARD_GRID)
+	}
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		return nil, err
+	}
+
+	return encoded.Bytes(), nil
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+// The layout of a rendered card, in pixels. The days are drawn as a 7x7 grid
+// below a header holding the title and motivation of the goal.
+const (
+	CARD_CELL_SIZE = 50
+	CARD_MARGIN    = 35
+	CARD_HEADER    = 100
+	CARD_FOOTER    = 35
+	CARD_WIDTH     = 2*CARD_MARGIN + 7*CARD_CELL_SIZE
+	CARD_HEIGHT    = CARD_HEADER + 7*CARD_CELL_SIZE + CARD_FOOTER
+)
+
+// The colours of a rendered card.
+const (
+	CARD_BACKGROUND = "#ffffff"
+	CARD_TEXT       = "#222222"
+	CARD_MUTED_TEXT = "#777777"
+	CARD_GRID       = "#444444"
+	CARD_MARK       = "#c0392b"
+	CARD_TODAY      = "#fff3b0"
+	CARD_MISSED     = "#eeeeee"
+	CARD_REST       = "#bbbbbb"
+)
+
+// cardImage holds what is drawn on a rendered card.
+type cardImage struct {
+	Title      string
+	Motivation string
+	Caption    string
+	Footer     string
+	Days       []xeffect.CardDay
+	Today      string
+}
+
+func newCardImage(goal xeffect.Goal, card xeffect.Card, today string) cardImage {
+	return cardImage{
+		Title:      goal.Title,
+		Motivation: goal.Motivation,
+		Caption:    fmt.Sprintf("Card %d: %s to %s, %s", card.Number, card.StartDate, card.EndDate, card.Status),
+		Footer:     fmt.Sprintf("%d of %d days completed", card.CompletedDays, xeffect.CARD_DAYS),
+		Days:       card.Days,
+		Today:      today,
+	}
+}
+
+// cell returns the top left corner of the cell of the day at index.
+func cell(index int) (int, int) {
+	return CARD_MARGIN + (index%7)*CARD_CELL_SIZE, CARD_HEADER + (index/7)*CARD_CELL_SIZE
+}
+
+// truncate shortens text to at most length runes, ending it with an ellipsis
+// when any are removed.
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	return string(runes[:length-1]) + "…"
+}
+
+func escape(text string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(text))
+
+	return escaped.String()
+}
+
+// renderSVG draws the card as an SVG image.
+func renderSVG(card cardImage) []byte {
+	var svg bytes.Buffer
+
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, CARD_WIDTH, CARD_HEIGHT, CARD_WIDTH, CARD_HEIGHT)
+	fmt.Fprintf(&svg, `<rect width="%d" height="%d" fill="%s"/>`, CARD_WIDTH, CARD_HEIGHT, CARD_BACKGROUND)
+	fmt.Fprintf(&svg, `<g font-family="sans-serif">`)
+	fmt.Fprintf(&svg, `<text x="%d" y="40" font-size="20" font-weight="bold" fill="%s">%s</text>`, CARD_MARGIN, CARD_TEXT, escape(truncate(card.Title, 30)))
+	fmt.Fprintf(&svg, `<text x="%d" y="64" font-size="14" fill="%s">%s</text>`, CARD_MARGIN, CARD_TEXT, escape(truncate(card.Motivation, 45)))
+	fmt.Fprintf(&svg, `<text x="%d" y="86" font-size="12" fill="%s">%s</text>`, CARD_MARGIN, CARD_MUTED_TEXT, escape(card.Caption))
+	fmt.Fprintf(&svg, `<text x="%d" y="%d" font-size="12" fill="%s">%s</text>`, CARD_MARGIN, CARD_HEIGHT-12, CARD_MUTED_TEXT, escape(card.Footer))
+	fmt.Fprintf(&svg, `</g>`)
+
+	for i, day := range card.Days {
+		x, y := cell(i)
+
+		fill := ""
+		switch {
+		case day.Date == card.Today:
+			fill = CARD_TODAY
+		case day.Status == xeffect.CARD_DAY_MISSED:
+			fill = CARD_MISSED
+		}
+		if fill != "" {
+			fmt.Fprintf(&svg, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`, x, y, CARD_CELL_SIZE, CARD_CELL_SIZE, fill)
+		}
+
+		if day.Status == xeffect.CARD_DAY_COMPLETED {
+			inset := CARD_CELL_SIZE / 5
+			fmt.Fprintf(&svg, `<g stroke="%s" stroke-width="4" stroke-linecap="round">`, CARD_MARK)
+			fmt.Fprintf(&svg, `<line x1="%d" y1="%d" x2="%d" y2="%d"/>`, x+inset, y+inset, x+CARD_CELL_SIZE-inset, y+CARD_CELL_SIZE-inset)
+			fmt.Fprintf(&svg, `<line x1="%d" y1="%d" x2="%d" y2="%d"/>`, x+CARD_CELL_SIZE-inset, y+inset, x+inset, y+CARD_CELL_SIZE-inset)
+			fmt.Fprintf(&svg, `</g>`)
+		}
+
+		// Rest days are marked with a dash, so that they are not mistaken for days
+		// still to be completed.
+		if day.Status == xeffect.CARD_DAY_REST {
+			fmt.Fprintf(&svg, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="2"/>`, x+2*CARD_CELL_SIZE/5, y+CARD_CELL_SIZE/2, x+3*CARD_CELL_SIZE/5, y+CARD_CELL_SIZE/2, CARD_REST)
+		}
+	}
+
+	// The grid is drawn last, so that it is not covered by the cells.
+	fmt.Fprintf(&svg, `<g stroke="%s" stroke-width="1">`, CARD_GRID)
+	for i := 0; i <= 7; i++ {
+		offset := i * CARD_CELL_SIZE
+		fmt.Fprintf(&svg, `<line x1="%d" y1="%d" x2="%d" y2="%d"/>`, CARD_MARGIN+offset, CARD_HEADER, CARD_MARGIN+offset, CARD_HEADER+7*CARD_CELL_SIZE)
+		fmt.Fprintf(&svg, `<line x1="%d" y1="%d" x2="%d" y2="%d"/>`, CARD_MARGIN, CARD_HEADER+offset, CARD_MARGIN+7*CARD_CELL_SIZE, CARD_HEADER+offset)
+	}
+	fmt.Fprintf(&svg, `</g>`)
+
+	fmt.Fprintf(&svg, `</svg>`)
+
+	return svg.Bytes()
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+type Request events.APIGatewayProxyRequest
+type Response events.APIGatewayProxyResponse
+
+// MAX_RANGE_DAYS is the largest number of days that may be requested at once.
+const MAX_RANGE_DAYS = 366
+
+// Handler serves goal_get_completed requests using the goals held in a
+// GoalRepository.
+type Handler struct {
+	goals xeffect.GoalRepository
+}
+
+// New returns a Handler backed by goals.
+func New(goals xeffect.GoalRepository) *Handler {
+	return &Handler{goals: goals}
+}
+
+func returnError(err error) (Response, error) {
+	return Response{
+		StatusCode:      400,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "text/plain",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: err.Error(),
+	}, nil
+}
+
+func returnErrorResponse(statusCode int, code string, message string) (Response, error) {
+	response, err := xeffect.JSONErrorResponse(statusCode, code, message)
+	return Response(response), err
+}
+
+func returnNotFound(goalId string) (Response, error) {
+	return returnErrorResponse(404, "goal_not_found", fmt.Sprintf("Goal '%s' does not exist.", goalId))
+}
+
+func returnUnauthorized() (Response, error) {
+	response, err := xeffect.UnauthorizedResponse()
+	return Response(response), err
+}
+
+func parseDate(date string) (time.Time, error) {
+	return time.Parse("2006-01-02", date)
+}
+
+// completedDates returns whether the goal was completed on each day between
+// fromDate and toDate inclusive, keyed by date. The days a streak skipped, as
+// the schedule of the goal did not need them to be completed, were not
+// completed.
+func completedDates(goal xeffect.Goal, fromDate time.Time, toDate time.Time) (map[string]bool, error) {
+	completed := map[string]bool{}
+	for date := fromDate; !date.After(toDate); date = date.AddDate(0, 0, 1) {
+		completed[date.Format("2006-01-02")] = false
+	}
+
+	for streakDate, streak := range goal.Streaks {
+		streakStartDate, err := parseDate(streakDate)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only the days of the streak which fall within the range are marked.
+		date := streakStartDate
+		if date.Before(fromDate) {
+			date = fromDate
+		}
+
+		skipped := map[string]bool{}
+		for _, day := range streak.Skipped {
+			skipped[day] = true
+		}
+
+		streakEndDate := streakStartDate.AddDate(0, 0, streak.Length-1)
+		for ; !date.After(streakEndDate) && !date.After(toDate); date = date.AddDate(0, 0, 1) {
+			if day := date.Format("2006-01-02"); !skipped[day] {
+				completed[day] = true
+			}
+		}
+	}
+
+	return completed, nil
+}
+
+func (h *Handler) HandleGoalGetCompletedEvent(ctx context.Context, event Request) (Response, error) {
+	owner, ok := xeffect.RequestOwner(event.RequestContext)
+	if !ok {
+		return returnUnauthorized()
+	}
+
+	goalId := event.PathParameters["goalId"]
+
+	// A single date is given as part of the path, otherwise a range of dates is
+	// given by the from and to query parameters.
+	from, to := event.PathParameters["date"], event.PathParameters["date"]
+	if from == "" {
+		from, to = event.QueryStringParameters["from"], event.QueryStringParameters["to"]
+		if from == "" || to == "" {
+			return returnError(errors.New("'from' and 'to' dates are required"))
+		}
+	}
+
+	fromDate, err := parseDate(from)
+	if err != nil {
+		return returnError(err)
+	}
+
+	toDate, err := parseDate(to)
+	if err != nil {
+		return returnError(err)
+	}
+
+	if toDate.Before(fromDate) {
+		return returnError(fmt.Errorf("'%s' is before '%s'", to, from))
+	}
+
+	if days := int(toDate.Sub(fromDate).Hours()/24) + 1; days > MAX_RANGE_DAYS {
+		return returnError(fmt.Errorf("a range of at most %d days may be requested, not %d", MAX_RANGE_DAYS, days))
+	}
+
+	goal, err := h.goals.ForOwner(owner).GetGoal(ctx, goalId)
+	if errors.Is(err, xeffect.ErrGoalNotFound) {
+		return returnNotFound(goalId)
+	}
+
+	if err != nil {
+		return returnError(err)
+	}
+
+	completed, err := completedDates(goal, fromDate, toDate)
+	if err != nil {
+		return returnError(err)
+	}
+
+	body, err := json.Marshal(completed)
+	if err != nil {
+		return returnError(err)
+	}
+
+	return Response{
+		StatusCode:      200,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(body),
+	}, nil
+}
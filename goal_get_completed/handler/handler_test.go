@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/maxstanley/xeffect_backend/xeffect"
+	"github.com/maxstanley/xeffect_backend/xeffect/xeffecttest"
+)
+
+// newTestHandler returns a handler backed by memory, holding a single goal of
+// xeffecttest.OWNER completed on 2021-12-01 and 2021-12-03, skipping
+// 2021-12-02.
+func newTestHandler(t *testing.T) (*Handler, string) {
+	t.Helper()
+
+	goal := xeffecttest.NewGoal("goal-1")
+	goal.Streaks = map[string]xeffect.GoalStreak{
+		"2021-12-01": {Length: 3, Skipped: []string{"2021-12-02"}},
+	}
+	goal.StreakDates = []string{"2021-12-01"}
+	goal.BestStreak = 3
+	goal.Schedule = &xeffect.Schedule{Weekdays: []string{"wednesday", "friday"}}
+
+	return New(xeffecttest.NewGoalRepository(t, goal)), goal.Uuid
+}
+
+func TestCompletedRange(t *testing.T) {
+	handler, goalId := newTestHandler(t)
+
+	tests := []struct {
+		name      string
+		path      map[string]string
+		query     map[string]string
+		status    int
+		days      int
+		completed map[string]bool
+	}{
+		{
+			name:   "a single date",
+			path:   map[string]string{"date": "2021-12-01"},
+			status: 200,
+			days:   1,
+			completed: map[string]bool{
+				"2021-12-01": true,
+			},
+		},
+		{
+			name:   "a range around the streak",
+			query:  map[string]string{"from": "2021-11-30", "to": "2021-12-04"},
+			status: 200,
+			days:   5,
+			completed: map[string]bool{
+				"2021-11-30": false,
+				"2021-12-01": true,
+				"2021-12-02": false,
+				"2021-12-03": true,
+				"2021-12-04": false,
+			},
+		},
+		{
+			name:   "the same day",
+			query:  map[string]string{"from": "2021-12-03", "to": "2021-12-03"},
+			status: 200,
+			days:   1,
+		},
+		{
+			name:   "366 days inclusive",
+			query:  map[string]string{"from": "2021-01-01", "to": "2022-01-01"},
+			status: 200,
+			days:   366,
+		},
+		{
+			name:   "366 days of a leap year",
+			query:  map[string]string{"from": "2024-01-01", "to": "2024-12-31"},
+			status: 200,
+			days:   366,
+		},
+		{
+			name:   "367 days",
+			query:  map[string]string{"from": "2021-01-01", "to": "2022-01-02"},
+			status: 400,
+		},
+		{
+			name:   "an inverted range",
+			query:  map[string]string{"from": "2021-12-02", "to": "2021-12-01"},
+			status: 400,
+		},
+		{
+			name:   "without an end",
+			query:  map[string]string{"from": "2021-12-01"},
+			status: 400,
+		},
+		{
+			name:   "not a date",
+			query:  map[string]string{"from": "1 Dec", "to": "2021-12-02"},
+			status: 400,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := map[string]string{"goalId": goalId}
+			for name, value := range test.path {
+				path[name] = value
+			}
+
+			response, err := handler.HandleGoalGetCompletedEvent(context.Background(), Request{
+				HTTPMethod:            "GET",
+				PathParameters:        path,
+				QueryStringParameters: test.query,
+				RequestContext:        xeffecttest.RequestContext(xeffecttest.OWNER),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if response.StatusCode != test.status {
+				t.Fatalf("the request was answered with %d, want %d: %s", response.StatusCode, test.status, response.Body)
+			}
+
+			if test.status != 200 {
+				return
+			}
+
+			var completed map[string]bool
+			if err := json.Unmarshal([]byte(response.Body), &completed); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(completed) != test.days {
+				t.Errorf("%d days were returned, want %d", len(completed), test.days)
+			}
+
+			if test.completed != nil && !reflect.DeepEqual(completed, test.completed) {
+				t.Errorf("the days are %v, want %v", completed, test.completed)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+type Request events.APIGatewayProxyRequest
+type Response events.APIGatewayProxyResponse
+
+const (
+	DEFAULT_PAGE_SIZE = 50
+	MAX_PAGE_SIZE     = 100
+)
+
+// Handler serves goal_get_events requests using the goals held in a
+// GoalRepository.
+type Handler struct {
+	goals xeffect.GoalRepository
+}
+
+// New returns a Handler backed by goals.
+func New(goals xeffect.GoalRepository) *Handler {
+	return &Handler{goals: goals}
+}
+
+// EventsPage is a single page of the completion events of a goal, oldest first.
+// NextPageToken is only set when there are more events to be listed, and is
+// passed as the page_token of the next request.
+type EventsPage struct {
+	Events        []xeffect.CompletionEvent `json:"events"`
+	NextPageToken string                    `json:"next_page_token,omitempty"`
+}
+
+func returnError(err error) (Response, error) {
+	return Response{
+		StatusCode:      400,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "text/plain",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: err.Error(),
+	}, nil
+}
+
+func returnErrorResponse(statusCode int, code string, message string) (Response, error) {
+	response, err := xeffect.JSONErrorResponse(statusCode, code, message)
+	return Response(response), err
+}
+
+func returnNotFound(goalId string) (Response, error) {
+	return returnErrorResponse(404, "goal_not_found", fmt.Sprintf("Goal '%s' does not exist.", goalId))
+}
+
+func returnUnauthorized() (Response, error) {
+	response, err := xeffect.UnauthorizedResponse()
+	return Response(response), err
+}
+
+func (h *Handler) HandleGoalGetEventsEvent(ctx context.Context, event Request) (Response, error) {
+	owner, ok := xeffect.RequestOwner(event.RequestContext)
+	if !ok {
+		return returnUnauthorized()
+	}
+
+	goalId := event.PathParameters["goalId"]
+
+	pageSize := DEFAULT_PAGE_SIZE
+	if value, ok := event.QueryStringParameters["page_size"]; ok {
+		var err error
+		pageSize, err = strconv.Atoi(value)
+		if err != nil {
+			return returnError(err)
+		}
+
+		if pageSize < 1 || pageSize > MAX_PAGE_SIZE {
+			return returnError(fmt.Errorf("page_size must be between 1 and %d", MAX_PAGE_SIZE))
+		}
+	}
+
+	page, err := h.goals.ForOwner(owner).ListCompletionEvents(ctx, goalId, xeffect.CompletionEventQuery{
+		PageSize:  pageSize,
+		PageToken: event.QueryStringParameters["page_token"],
+	})
+	if errors.Is(err, xeffect.ErrGoalNotFound) {
+		return returnNotFound(goalId)
+	}
+
+	if errors.Is(err, xeffect.ErrInvalidPageToken) {
+		return returnError(fmt.Errorf("'%s' is not a valid page token", event.QueryStringParameters["page_token"]))
+	}
+
+	if err != nil {
+		return returnError(err)
+	}
+
+	body, err := json.Marshal(EventsPage{
+		Events:        page.Events,
+		NextPageToken: page.NextPageToken,
+	})
+	if err != nil {
+		return returnError(err)
+	}
+
+	return Response{
+		StatusCode:      200,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(body),
+	}, nil
+}
@@ -0,0 +1,20 @@
+data "archive_file" "goal_print" {
+  type = "zip"
+  source_file = "goal_print/goal_print"
+  output_path = "goal_print/${var.lambda_zip_file}"
+}
+
+resource "aws_lambda_function" "goal_print" {
+  function_name = "goal_print"
+  filename = data.archive_file.goal_print.output_path
+  handler = "goal_print"
+  source_code_hash = data.archive_file.goal_print.output_base64sha256
+  runtime = "go1.x"
+  memory_size = 128
+  timeout = 10
+  role = aws_iam_role.iam_for_lambda.arn
+
+  environment {
+    variables = local.auth_environment
+  }
+}
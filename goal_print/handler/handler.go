@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+type Request events.APIGatewayProxyRequest
+type Response events.APIGatewayProxyResponse
+
+// MAX_PRINT_GOALS is the most goals that can be printed at once, one card to a
+// page.
+const MAX_PRINT_GOALS = 25
+
+// Handler serves goal_print requests using the goals held in a GoalRepository.
+type Handler struct {
+	goals xeffect.GoalRepository
+}
+
+// New returns a Handler backed by goals.
+func New(goals xeffect.GoalRepository) *Handler {
+	return &Handler{goals: goals}
+}
+
+func returnError(err error) (Response, error) {
+	return Response{
+		StatusCode:      400,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "text/plain",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: err.Error(),
+	}, nil
+}
+
+func returnErrorResponse(statusCode int, code string, message string) (Response, error) {
+	response, err := xeffect.JSONErrorResponse(statusCode, code, message)
+	return Response(response), err
+}
+
+func returnNotFound(goalId string) (Response, error) {
+	return returnErrorResponse(404, "goal_not_found", fmt.Sprintf("Goal '%s' does not exist.", goalId))
+}
+
+func returnUnauthorized() (Response, error) {
+	response, err := xeffect.UnauthorizedResponse()
+	return Response(response), err
+}
+
+func parseDate(date string) (time.Time, error) {
+	return time.Parse("2006-01-02", date)
+}
+
+// blankCard returns a card of a goal starting on date with none of its days
+// marked, other than the rest days of its schedule.
+func blankCard(goal xeffect.Goal, date time.Time) xeffect.Card {
+	card := xeffect.Card{
+		Number:    1,
+		StartDate: date.Format("2006-01-02"),
+		EndDate:   date.AddDate(0, 0, xeffect.CARD_DAYS-1).Format("2006-01-02"),
+		Status:    xeffect.CARD_IN_PROGRESS,
+		Days:      make([]xeffect.CardDay, xeffect.CARD_DAYS),
+	}
+
+	for i := range card.Days {
+		day := date.AddDate(0, 0, i)
+		card.Days[i] = xeffect.CardDay{
+			Date:   day.Format("2006-01-02"),
+			Status: xeffect.CARD_DAY_PENDING,
+		}
+
+		if goal.Schedule.Skippable(day, map[string]bool{}) {
+			card.Days[i].Status = xeffect.CARD_DAY_REST
+		}
+	}
+
+	return card
+}
+
+// listGoals returns every goal which is not archived, as long as there are few
+// enough to be printed at once. One more goal than can be printed is asked for,
+// as a page may have a next page token even when there are no more goals.
+func listGoals(ctx context.Context, goals xeffect.GoalRepository) ([]xeffect.Goal, error) {
+	page, err := goals.ListGoals(ctx, xeffect.GoalQuery{
+		PageSize: MAX_PRINT_GOALS + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(page.Goals) > MAX_PRINT_GOALS {
+		return nil, fmt.Errorf("at most %d goals can be printed at once, so goal_ids must be given", MAX_PRINT_GOALS)
+	}
+
+	return page.Goals, nil
+}
+
+// HandleGoalPrintEvent returns a PDF with a page for the card of each goal, to
+// be printed and kept alongside the goals. The cards are filled in with the
+// days completed on the current card of each goal, or are left blank to start
+// on the date when blank is set.
+func (h *Handler) HandleGoalPrintEvent(ctx context.Context, event Request) (Response, error) {
+	owner, ok := xeffect.RequestOwner(event.RequestContext)
+	if !ok {
+		return returnUnauthorized()
+	}
+
+	date := event.QueryStringParameters["date"]
+	if date == "" {
+		date = time.Now().UTC().Format("2006-01-02")
+	}
+
+	asOfDate, err := parseDate(date)
+	if err != nil {
+		return returnError(err)
+	}
+
+	blank := false
+	if value, ok := event.QueryStringParameters["blank"]; ok {
+		blank, err = strconv.ParseBool(value)
+		if err != nil {
+			return returnError(err)
+		}
+	}
+
+	goalIds := []string{}
+	for _, goalId := range strings.Split(event.QueryStringParameters["goal_ids"], ",") {
+		if goalId = strings.TrimSpace(goalId); goalId != "" {
+			goalIds = append(goalIds, goalId)
+		}
+	}
+
+	if len(goalIds) > MAX_PRINT_GOALS {
+		return returnError(fmt.Errorf("at most %d goals can be printed at once", MAX_PRINT_GOALS))
+	}
+
+	goals := make([]xeffect.Goal, 0, len(goalIds))
+	for _, goalId := range goalIds {
+		goal, err := h.goals.ForOwner(owner).GetGoal(ctx, goalId)
+		if errors.Is(err, xeffect.ErrGoalNotFound) {
+			return returnNotFound(goalId)
+		}
+
+		if err != nil {
+			return returnError(err)
+		}
+
+		goals = append(goals, goal)
+	}
+
+	if len(goalIds) == 0 {
+		goals, err = listGoals(ctx, h.goals.ForOwner(owner))
+		if err != nil {
+			return returnError(err)
+		}
+	}
+
+	if len(goals) == 0 {
+		return returnError(errors.New("there are no goals to print"))
+	}
+
+	var document pdfDocument
+	for _, goal := range goals {
+		card := blankCard(goal, asOfDate)
+		if !blank {
+			cards, err := xeffect.GoalCards(goal, date)
+			if err != nil {
+				return returnError(err)
+			}
+
+			card = cards[len(cards)-1]
+		}
+
+		document.addPage(printCard(goal, card, blank, asOfDate))
+	}
+
+	return Response{
+		StatusCode:      200,
+		IsBase64Encoded: true,
+		Headers: map[string]string{
+			"Content-Type":                "application/pdf",
+			"Content-Disposition":         `inline; filename="xeffect-cards.pdf"`,
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: base64.StdEncoding.EncodeToString(document.bytes()),
+	}, nil
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/maxstanley/xeffect_backend/xeffect"
+	"github.com/maxstanley/xeffect_backend/xeffect/xeffecttest"
+)
+
+// fullPageGoals gives every full page of goals a next page token, as DynamoDB
+// does when it stops reading at the limit, even if there are no more goals.
+type fullPageGoals struct {
+	xeffect.GoalRepository
+}
+
+func (r fullPageGoals) ForOwner(owner string) xeffect.GoalRepository {
+	return fullPageGoals{r.GoalRepository.ForOwner(owner)}
+}
+
+func (r fullPageGoals) ListGoals(ctx context.Context, query xeffect.GoalQuery) (xeffect.GoalPage, error) {
+	page, err := r.GoalRepository.ListGoals(ctx, query)
+	if err == nil && len(page.Goals) == query.PageSize {
+		page.NextPageToken = "next"
+	}
+
+	return page, err
+}
+
+func TestPrintEveryGoal(t *testing.T) {
+	goals := xeffecttest.NewGoalRepository(t)
+	handler := New(fullPageGoals{goals})
+
+	request := Request{
+		QueryStringParameters: map[string]string{"date": "2021-12-31"},
+		RequestContext:        xeffecttest.RequestContext(xeffecttest.OWNER),
+	}
+
+	for i := 0; i <= MAX_PRINT_GOALS; i++ {
+		xeffecttest.CreateGoals(t, goals, xeffecttest.OWNER, xeffecttest.NewGoal(fmt.Sprintf("goal-%02d", i)))
+
+		response, err := handler.HandleGoalPrintEvent(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := 200
+		if i+1 > MAX_PRINT_GOALS {
+			want = 400
+		}
+
+		if response.StatusCode != want {
+			t.Fatalf("printing %d goals was answered with %d, want %d: %s", i+1, response.StatusCode, want, response.Body)
+		}
+	}
+}
+
+func TestPrintDate(t *testing.T) {
+	goal := xeffecttest.NewGoal("goal-1")
+	goal.Streaks = map[string]xeffect.GoalStreak{"2021-12-01": {Length: 3}}
+	goal.StreakDates = []string{"2021-12-01"}
+	goal.BestStreak = 3
+	handler := New(xeffecttest.NewGoalRepository(t, goal))
+
+	tests := []struct {
+		name       string
+		parameters map[string]string
+		want       []string
+	}{
+		{
+			name:       "current card",
+			parameters: map[string]string{"date": "2021-12-03"},
+			want:       []string{"(Printed 2021-12-03)", "(Card 1, started 2021-12-01: 3 of 49 days completed)"},
+		},
+		{
+			name:       "blank card",
+			parameters: map[string]string{"date": "2022-01-03", "blank": "true"},
+			want:       []string{"(Printed 2022-01-03)", "(Start date: 2022-01-03)"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response, err := handler.HandleGoalPrintEvent(context.Background(), Request{
+				QueryStringParameters: test.parameters,
+				RequestContext:        xeffecttest.RequestContext(xeffecttest.OWNER),
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if response.StatusCode != 200 || response.Headers["Content-Type"] != "application/pdf" {
+				t.Fatalf("the request was answered with %d and '%s', want 200 and 'application/pdf'", response.StatusCode, response.Headers["Content-Type"])
+			}
+
+			document, err := base64.StdEncoding.DecodeString(response.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, text := range test.want {
+				if !bytes.Contains(document, []byte(text)) {
+					t.Errorf("the card does not show %s", text)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// pdfDocument builds a PDF of A4 pages, using the Helvetica fonts every PDF
+// reader provides, so that no font needs to be embedded.
+type pdfDocument struct {
+	pages []string
+}
+
+// The size of an A4 page, in points.
+const (
+	PDF_PAGE_WIDTH  = 595
+	PDF_PAGE_HEIGHT = 842
+)
+
+// The fonts of each page, as they are named in content streams.
+const (
+	PDF_FONT_REGULAR = "F1"
+	PDF_FONT_BOLD    = "F2"
+)
+
+// pdfPage collects the content stream of a single page. Coordinates are in
+// points from the bottom left of the page.
+type pdfPage struct {
+	content strings.Builder
+}
+
+// winAnsiPunctuation maps the punctuation outside of Latin-1 most often typed
+// into titles onto the WinAnsi encoding.
+var winAnsiPunctuation = map[rune]byte{
+	'…': 0x85,
+	'‘': 0x91,
+	'’': 0x92,
+	'“': 0x93,
+	'”': 0x94,
+	'•': 0x95,
+	'–': 0x96,
+	'—': 0x97,
+}
+
+// pdfString encodes text as a PDF string in the WinAnsi encoding of the fonts.
+// Characters the encoding can not represent are replaced by a question mark.
+func pdfString(text string) string {
+	var encoded strings.Builder
+	encoded.WriteByte('(')
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			encoded.WriteByte('\\')
+			encoded.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			encoded.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&encoded, "\\%03o", r)
+		case winAnsiPunctuation[r] != 0:
+			fmt.Fprintf(&encoded, "\\%03o", winAnsiPunctuation[r])
+		default:
+			encoded.WriteByte('?')
+		}
+	}
+	encoded.WriteByte(')')
+
+	return encoded.String()
+}
+
+func (p *pdfPage) text(x float64, y float64, font string, size float64, grey float64, text string) {
+	fmt.Fprintf(&p.content, "BT %.2f g /%s %.1f Tf %.2f %.2f Td %s Tj ET\n", grey, font, size, x, y, pdfString(text))
+}
+
+func (p *pdfPage) line(x0 float64, y0 float64, x1 float64, y1 float64, width float64, r float64, g float64, b float64) {
+	fmt.Fprintf(&p.content, "%.2f %.2f %.2f RG %.2f w 1 J %.2f %.2f m %.2f %.2f l S\n", r, g, b, width, x0, y0, x1, y1)
+}
+
+func (p *pdfPage) fillRect(x float64, y float64, width float64, height float64, grey float64) {
+	fmt.Fprintf(&p.content, "%.2f g %.2f %.2f %.2f %.2f re f\n", grey, x, y, width, height)
+}
+
+func (d *pdfDocument) addPage(page *pdfPage) {
+	d.pages = append(d.pages, page.content.String())
+}
+
+// bytes writes the document. The catalog, page tree and fonts are the first
+// four objects, followed by each page and its content stream.
+func (d *pdfDocument) bytes() []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>",
+	}
+
+	kids := make([]string, 0, len(d.pages))
+	for _, content := range d.pages {
+		page := len(objects) + 1
+		kids = append(kids, fmt.Sprintf("%d 0 R", page))
+
+		objects = append(objects, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>", PDF_PAGE_WIDTH, PDF_PAGE_HEIGHT, PDF_FONT_REGULAR, PDF_FONT_BOLD, page+1))
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", len(content), content))
+	}
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = pdf.Len()
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return pdf.Bytes()
+}
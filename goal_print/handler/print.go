@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+// The layout of a printed card, in points. The days are drawn as a 7x7 grid,
+// centred on the page below the title and motivation of the goal.
+const (
+	PRINT_CELL_SIZE = 65
+	PRINT_MARGIN    = (PDF_PAGE_WIDTH - 7*PRINT_CELL_SIZE) / 2
+	PRINT_GRID_TOP  = 680
+)
+
+// truncate shortens text to at most length runes, ending it with an ellipsis
+// when any are removed.
+func truncate(text string, length int) string {
+	runes := []rune(text)
+	if len(runes) <= length {
+		return text
+	}
+
+	return string(runes[:length-1]) + "…"
+}
+
+// printCard draws the card of a goal on a page, as printed on date. Each day is
+// numbered and dated, the days completed on a card which is not blank are
+// crossed out, and rest days are marked with a dash.
+func printCard(goal xeffect.Goal, card xeffect.Card, blank bool, date time.Time) *pdfPage {
+	page := &pdfPage{}
+
+	caption := fmt.Sprintf("Start date: %s", card.StartDate)
+	if !blank {
+		caption = fmt.Sprintf("Card %d, started %s: %d of %d days completed", card.Number, card.StartDate, card.CompletedDays, xeffect.CARD_DAYS)
+	}
+
+	page.text(PRINT_MARGIN, 770, PDF_FONT_BOLD, 24, 0.13, truncate(goal.Title, 32))
+	page.text(PRINT_MARGIN, 745, PDF_FONT_REGULAR, 14, 0.13, truncate(goal.Motivation, 60))
+	page.text(PRINT_MARGIN, 722, PDF_FONT_REGULAR, 11, 0.47, caption)
+
+	for i, day := range card.Days {
+		x := float64(PRINT_MARGIN + (i%7)*PRINT_CELL_SIZE)
+		y := float64(PRINT_GRID_TOP - (i/7+1)*PRINT_CELL_SIZE)
+
+		if !blank && day.Status == xeffect.CARD_DAY_MISSED {
+			page.fillRect(x, y, PRINT_CELL_SIZE, PRINT_CELL_SIZE, 0.93)
+		}
+
+		page.text(x+4, y+PRINT_CELL_SIZE-11, PDF_FONT_REGULAR, 8, 0.47, strconv.Itoa(i+1))
+		if dayDate, err := parseDate(day.Date); err == nil {
+			page.text(x+4, y+4, PDF_FONT_REGULAR, 7, 0.6, dayDate.Format("2 Jan"))
+		}
+
+		if !blank && day.Status == xeffect.CARD_DAY_COMPLETED {
+			inset := float64(PRINT_CELL_SIZE) / 5
+			page.line(x+inset, y+inset, x+PRINT_CELL_SIZE-inset, y+PRINT_CELL_SIZE-inset, 4, 0.75, 0.22, 0.17)
+			page.line(x+PRINT_CELL_SIZE-inset, y+inset, x+inset, y+PRINT_CELL_SIZE-inset, 4, 0.75, 0.22, 0.17)
+		}
+
+		if day.Status == xeffect.CARD_DAY_REST {
+			page.line(x+2*PRINT_CELL_SIZE/5, y+PRINT_CELL_SIZE/2, x+3*PRINT_CELL_SIZE/5, y+PRINT_CELL_SIZE/2, 2, 0.73, 0.73, 0.73)
+		}
+	}
+
+	// The grid is drawn last, so that it is not covered by the cells.
+	bottom := float64(PRINT_GRID_TOP - 7*PRINT_CELL_SIZE)
+	for i := 0; i <= 7; i++ {
+		offset := float64(i * PRINT_CELL_SIZE)
+		page.line(PRINT_MARGIN+offset, bottom, PRINT_MARGIN+offset, PRINT_GRID_TOP, 1, 0.27, 0.27, 0.27)
+		page.line(PRINT_MARGIN, bottom+offset, PRINT_MARGIN+7*PRINT_CELL_SIZE, bottom+offset, 1, 0.27, 0.27, 0.27)
+	}
+
+	page.text(PRINT_MARGIN, bottom-25, PDF_FONT_REGULAR, 9, 0.6, fmt.Sprintf("Printed %s", date.Format("2006-01-02")))
+
+	return page
+}
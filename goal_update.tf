@@ -0,0 +1,20 @@
+data "archive_file" "goal_update" {
+  type = "zip"
+  source_file = "goal_update/goal_update"
+  output_path = "goal_update/${var.lambda_zip_file}"
+}
+
+resource "aws_lambda_function" "goal_update" {
+  function_name = "goal_update"
+  filename = data.archive_file.goal_update.output_path
+  handler = "goal_update"
+  source_code_hash = data.archive_file.goal_update.output_base64sha256
+  runtime = "go1.x"
+  memory_size = 128
+  timeout = 10
+  role = aws_iam_role.iam_for_lambda.arn
+
+  environment {
+    variables = local.auth_environment
+  }
+}
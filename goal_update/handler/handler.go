@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/go-playground/validator/v10"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+type Request events.APIGatewayProxyRequest
+type Response events.APIGatewayProxyResponse
+
+// Handler serves goal_update requests using the goals held in a GoalRepository.
+type Handler struct {
+	goals xeffect.GoalRepository
+}
+
+// New returns a Handler backed by goals.
+func New(goals xeffect.GoalRepository) *Handler {
+	return &Handler{goals: goals}
+}
+
+// MAX_UPDATE_ATTEMPTS is the number of times an update is tried when the goal
+// is changed by another request at the same time.
+const MAX_UPDATE_ATTEMPTS = 3
+
+// GoalUpdate holds the fields of a goal that may be changed. A PUT must
+// provide the title and motivation, whereas a PATCH must provide at least one
+// field. The schedule and target are only changed when they are given. An
+// empty schedule removes it, so that the goal is to be completed every day, and
+// an empty target removes it.
+type GoalUpdate struct {
+	Title      *string           `json:"title" validate:"omitempty,min=1"`
+	Motivation *string           `json:"motivation" validate:"omitempty,min=1"`
+	Schedule   *xeffect.Schedule `json:"schedule"`
+	Target     *xeffect.Target   `json:"target"`
+}
+
+func returnError(err error) (Response, error) {
+	return Response{
+		StatusCode:      400,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "text/plain",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: err.Error(),
+	}, nil
+}
+
+func returnErrorResponse(statusCode int, code string, message string) (Response, error) {
+	response, err := xeffect.JSONErrorResponse(statusCode, code, message)
+	return Response(response), err
+}
+
+func returnNotFound(goalId string) (Response, error) {
+	return returnErrorResponse(404, "goal_not_found", fmt.Sprintf("Goal '%s' does not exist.", goalId))
+}
+
+func returnUnauthorized() (Response, error) {
+	response, err := xeffect.UnauthorizedResponse()
+	return Response(response), err
+}
+
+func returnConflict(goalId string) (Response, error) {
+	return returnErrorResponse(409, "goal_conflict", fmt.Sprintf("Goal '%s' is being changed by another request, please try again.", goalId))
+}
+
+// sameSchedule reports whether two schedules lay out the same days, whatever
+// the order or case their weekdays are given in.
+func sameSchedule(a xeffect.Schedule, b xeffect.Schedule) bool {
+	if a.EveryDays != b.EveryDays || a.StartDate != b.StartDate || a.TimesPerWeek != b.TimesPerWeek || len(a.Weekdays) != len(b.Weekdays) {
+		return false
+	}
+
+	weekdays := map[string]bool{}
+	for _, weekday := range a.Weekdays {
+		weekdays[strings.ToLower(weekday)] = true
+	}
+	for _, weekday := range b.Weekdays {
+		if !weekdays[strings.ToLower(weekday)] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// updateGoal changes the fields of a goal, and replaces its schedule when one
+// is given, as long as the goal is not changed by another request at the same
+// time. The streaks of the goal are laid out again for a new schedule before
+// anything is written, and are written in the same update as the fields, so
+// either every change is made or none of them is. Setting the schedule a goal
+// already has changes nothing.
+//
+// A schedule repeating every so many days without a start date keeps the start
+// date of the goal's schedule when it repeats at the same interval, so that
+// sending a goal's schedule back unchanged does not move its days, and
+// otherwise starts on today.
+func updateGoal(ctx context.Context, goals xeffect.GoalRepository, id string, update xeffect.GoalUpdate, schedule *xeffect.Schedule, today string) (xeffect.Goal, error) {
+	goal, err := goals.GetGoal(ctx, id)
+	if err != nil {
+		return xeffect.Goal{}, err
+	}
+	update.Version = goal.Version
+
+	current := xeffect.Schedule{}
+	if goal.Schedule != nil {
+		current = *goal.Schedule
+	}
+
+	if schedule != nil && schedule.EveryDays != 0 && schedule.StartDate == "" {
+		defaulted := *schedule
+		defaulted.StartDate = today
+		if current.EveryDays == schedule.EveryDays {
+			defaulted.StartDate = current.StartDate
+		}
+		schedule = &defaulted
+	}
+
+	if schedule != nil && !sameSchedule(current, *schedule) {
+		days, err := xeffect.CompletedDays(goal)
+		if err != nil {
+			return xeffect.Goal{}, err
+		}
+
+		goal.Schedule = nil
+		if !schedule.IsEmpty() {
+			goal.Schedule = schedule
+		}
+
+		streaks, err := xeffect.RebuildStreaks(goal, days)
+		if err != nil {
+			return xeffect.Goal{}, err
+		}
+		streaks.Schedule = schedule
+		update.Streaks = &streaks
+	}
+
+	return goals.UpdateGoal(ctx, id, update)
+}
+
+func (h *Handler) HandleGoalUpdateEvent(ctx context.Context, event Request) (Response, error) {
+	owner, ok := xeffect.RequestOwner(event.RequestContext)
+	if !ok {
+		return returnUnauthorized()
+	}
+
+	goalId := event.PathParameters["goalId"]
+
+	var body []byte
+	if event.IsBase64Encoded {
+		var err error
+		body, err = base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			return returnError(err)
+		}
+	} else {
+		body = []byte(event.Body)
+	}
+
+	contentType := event.Headers["content-type"]
+	if contentType != "application/json" {
+		headers, _ := json.Marshal(event.Headers)
+		return returnError(fmt.Errorf("'%s' is not a supported Content-Type.\n%s", contentType, string(headers)))
+	}
+
+	var update GoalUpdate
+	if err := json.Unmarshal(body, &update); err != nil {
+		return returnError(err)
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(update); err != nil {
+		return returnError(err)
+	}
+
+	switch event.HTTPMethod {
+	case "PUT":
+		if update.Title == nil || update.Motivation == nil {
+			return returnError(errors.New("'title' and 'motivation' are required to replace a goal"))
+		}
+	case "PATCH":
+		if update.Title == nil && update.Motivation == nil && update.Schedule == nil && update.Target == nil {
+			return returnError(errors.New("at least one of 'title', 'motivation', 'schedule' or 'target' is required to update a goal"))
+		}
+	default:
+		return returnError(fmt.Errorf("'%s' is not a supported method", event.HTTPMethod))
+	}
+
+	// A schedule repeating every so many days is given its start date once the
+	// goal has been read, so it is checked with today in its place.
+	today := time.Now().UTC().Format("2006-01-02")
+	if update.Schedule != nil && !update.Schedule.IsEmpty() {
+		schedule := *update.Schedule
+		if schedule.EveryDays != 0 && schedule.StartDate == "" {
+			schedule.StartDate = today
+		}
+
+		if err := schedule.Validate(); err != nil {
+			return returnError(err)
+		}
+	}
+
+	if update.Target != nil && !update.Target.IsEmpty() {
+		if err := update.Target.Validate(); err != nil {
+			return returnError(err)
+		}
+	}
+
+	goals := h.goals.ForOwner(owner)
+
+	var (
+		goal xeffect.Goal
+		err  error
+	)
+	for attempt := 0; attempt < MAX_UPDATE_ATTEMPTS; attempt++ {
+		goal, err = updateGoal(ctx, goals, goalId, xeffect.GoalUpdate{
+			Title:      update.Title,
+			Motivation: update.Motivation,
+			Target:     update.Target,
+		}, update.Schedule, today)
+		if !errors.Is(err, xeffect.ErrVersionConflict) {
+			break
+		}
+	}
+
+	if errors.Is(err, xeffect.ErrGoalNotFound) {
+		return returnNotFound(goalId)
+	}
+
+	if errors.Is(err, xeffect.ErrVersionConflict) {
+		return returnConflict(goalId)
+	}
+
+	if err != nil {
+		return returnError(err)
+	}
+
+	responseBody, err := json.Marshal(goal)
+	if err != nil {
+		return returnError(err)
+	}
+
+	return Response{
+		StatusCode:      200,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(responseBody),
+	}, nil
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/maxstanley/xeffect_backend/xeffect"
+	"github.com/maxstanley/xeffect_backend/xeffect/xeffecttest"
+)
+
+// completedGoal returns a daily goal completed from 2021-12-01 to 2021-12-05.
+func completedGoal() xeffect.Goal {
+	goal := xeffecttest.NewGoal("goal-1")
+	goal.Streaks = map[string]xeffect.GoalStreak{
+		"2021-12-01": {Length: 5},
+	}
+	goal.StreakDates = []string{"2021-12-01"}
+	goal.BestStreak = 5
+
+	return goal
+}
+
+// patch makes a PATCH request as xeffecttest.OWNER, failing the test unless
+// it is answered with status.
+func patch(t *testing.T, handler *Handler, goalId string, body string, status int) Response {
+	t.Helper()
+
+	response, err := handler.HandleGoalUpdateEvent(context.Background(), Request{
+		HTTPMethod:     "PATCH",
+		Resource:       "/xeffect/goals/{goalId}",
+		PathParameters: map[string]string{"goalId": goalId},
+		Headers:        map[string]string{"content-type": "application/json"},
+		RequestContext: xeffecttest.RequestContext(xeffecttest.OWNER),
+		Body:           body,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != status {
+		t.Fatalf("%s was answered with %d, want %d: %s", body, response.StatusCode, status, response.Body)
+	}
+
+	return response
+}
+
+func getGoal(t *testing.T, goals xeffect.GoalRepository, goalId string) xeffect.Goal {
+	t.Helper()
+
+	goal, err := goals.GetGoal(context.Background(), goalId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return goal
+}
+
+func TestUpdateFieldsAndSchedule(t *testing.T) {
+	goals := xeffecttest.NewGoalRepository(t, completedGoal())
+	handler, goalId := New(goals), "goal-1"
+
+	response := patch(t, handler, goalId, `{"title": "Write", "schedule": {"weekdays": ["wednesday", "friday"]}}`, 200)
+
+	var goal xeffect.Goal
+	if err := json.Unmarshal([]byte(response.Body), &goal); err != nil {
+		t.Fatal(err)
+	}
+
+	// The owner of a goal is not returned.
+	stored := getGoal(t, goals, goalId)
+	goal.Owner = stored.Owner
+	if !reflect.DeepEqual(stored, goal) {
+		t.Errorf("the stored goal %+v does not match the goal returned %+v", stored, goal)
+	}
+
+	if stored.Title != "Write" || stored.Motivation != "Learn" {
+		t.Errorf("the title and motivation are '%s' and '%s', want 'Write' and 'Learn'", stored.Title, stored.Motivation)
+	}
+
+	if stored.Schedule == nil || !reflect.DeepEqual(stored.Schedule.Weekdays, []string{"wednesday", "friday"}) {
+		t.Errorf("the schedule is %+v, want wednesdays and fridays", stored.Schedule)
+	}
+
+	// The fields and the streaks are changed by a single write.
+	if stored.Version != 1 {
+		t.Errorf("the goal is at version %d, want 1", stored.Version)
+	}
+
+	if problems := xeffect.CheckStreaks(stored); len(problems) > 0 {
+		t.Errorf("the stored streaks are inconsistent: %v", problems)
+	}
+
+	days, err := xeffect.CompletedDays(stored)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(days) != 5 {
+		t.Errorf("%d days are completed, want 5", len(days))
+	}
+
+	// Setting the same schedule again only changes the other fields.
+	patch(t, handler, goalId, `{"motivation": "Think", "schedule": {"weekdays": ["wednesday", "friday"]}}`, 200)
+
+	stored = getGoal(t, goals, goalId)
+	if stored.Motivation != "Think" || stored.Version != 2 {
+		t.Errorf("the goal has motivation '%s' at version %d, want 'Think' at version 2", stored.Motivation, stored.Version)
+	}
+}
+
+func TestUpdateInvalidSchedule(t *testing.T) {
+	goals := xeffecttest.NewGoalRepository(t, completedGoal())
+	handler, goalId := New(goals), "goal-1"
+
+	patch(t, handler, goalId, `{"title": "Write", "schedule": {"weekdays": ["someday"]}}`, 400)
+	patch(t, handler, goalId, `{"title": "Write", "schedule": {"weekdays": ["monday"], "times_per_week": 2}}`, 400)
+
+	stored := getGoal(t, goals, goalId)
+	if stored.Title != "Read" || stored.Schedule != nil || stored.Version != 0 {
+		t.Errorf("the goal was changed by an invalid schedule: %+v", stored)
+	}
+}
+
+// conflictingGoals changes the goal before every update, as if another request
+// was changing it at the same time.
+type conflictingGoals struct {
+	xeffect.GoalRepository
+}
+
+func (r conflictingGoals) ForOwner(owner string) xeffect.GoalRepository {
+	return conflictingGoals{r.GoalRepository.ForOwner(owner)}
+}
+
+func (r conflictingGoals) UpdateGoal(ctx context.Context, id string, update xeffect.GoalUpdate) (xeffect.Goal, error) {
+	goal, err := r.GoalRepository.GetGoal(ctx, id)
+	if err != nil {
+		return xeffect.Goal{}, err
+	}
+
+	if _, err := r.GoalRepository.SetGoalArchived(ctx, id, goal.Archived, goal.Version); err != nil {
+		return xeffect.Goal{}, err
+	}
+
+	return r.GoalRepository.UpdateGoal(ctx, id, update)
+}
+
+func TestUpdateConflict(t *testing.T) {
+	goals := xeffecttest.NewGoalRepository(t, completedGoal())
+	handler, goalId := New(conflictingGoals{goals}), "goal-1"
+
+	patch(t, handler, goalId, `{"title": "Write", "schedule": {"times_per_week": 3}}`, 409)
+
+	// Neither the title nor the schedule is changed when the update conflicts.
+	stored := getGoal(t, goals.ForOwner(xeffecttest.OWNER), goalId)
+	if stored.Title != "Read" || stored.Schedule != nil {
+		t.Errorf("the goal was changed by a conflicting update: %+v", stored)
+	}
+
+	if stored.Version != MAX_UPDATE_ATTEMPTS {
+		t.Errorf("the goal is at version %d, want %d", stored.Version, MAX_UPDATE_ATTEMPTS)
+	}
+}
+
+func TestUpdateEveryDaysStartDate(t *testing.T) {
+	goal := xeffecttest.NewGoal("goal-1")
+	goal.Streaks = map[string]xeffect.GoalStreak{
+		"2021-12-01": {Length: 5, Skipped: []string{"2021-12-02", "2021-12-04"}},
+	}
+	goal.StreakDates = []string{"2021-12-01"}
+	goal.BestStreak = 5
+	goal.UndoHistory = []xeffect.StreakSnapshot{{
+		Streaks:     map[string]xeffect.GoalStreak{"2021-12-01": {Length: 3, Skipped: []string{"2021-12-02"}}},
+		StreakDates: []string{"2021-12-01"},
+		BestStreak:  3,
+	}}
+	goal.Schedule = &xeffect.Schedule{EveryDays: 2, StartDate: "2021-12-01"}
+
+	goals := xeffecttest.NewGoalRepository(t, goal)
+	handler := New(goals)
+
+	// Sending the same interval without a start date keeps the days of the
+	// schedule, so the streaks and the undo history are left as they are.
+	patch(t, handler, goal.Uuid, `{"title": "Swim", "schedule": {"every_days": 2}}`, 200)
+
+	stored := getGoal(t, goals, goal.Uuid)
+	if stored.Title != "Swim" || stored.Schedule == nil || stored.Schedule.StartDate != "2021-12-01" {
+		t.Errorf("the goal has title '%s' and schedule %+v, want 'Swim' every 2 days from 2021-12-01", stored.Title, stored.Schedule)
+	}
+
+	if !reflect.DeepEqual(stored.Streaks, goal.Streaks) || len(stored.UndoHistory) != 1 {
+		t.Errorf("the streaks %v and undo history %v were changed", stored.Streaks, stored.UndoHistory)
+	}
+
+	// A new interval starts today, laying the streaks out again.
+	patch(t, handler, goal.Uuid, `{"schedule": {"every_days": 3}}`, 200)
+
+	stored = getGoal(t, goals, goal.Uuid)
+	if stored.Schedule == nil || stored.Schedule.EveryDays != 3 || stored.Schedule.StartDate == "2021-12-01" {
+		t.Errorf("the schedule is %+v, want every 3 days from today", stored.Schedule)
+	}
+
+	if len(stored.UndoHistory) != 0 {
+		t.Errorf("the undo history %v was kept when the streaks were laid out again", stored.UndoHistory)
+	}
+
+	if problems := xeffect.CheckStreaks(stored); len(problems) > 0 {
+		t.Errorf("the stored streaks are inconsistent: %v", problems)
+	}
+}
@@ -4,6 +4,30 @@ variable "lambda_zip_file" {
   default = "lambda.zip"
 }
 
+variable "jwks_url" {
+  type = string
+  description = "The url of the JSON Web Key Set bearer tokens are verified with. When empty, requests must be authorized by API Gateway."
+  default = ""
+}
+
+variable "jwt_issuer" {
+  type = string
+  description = "The issuer bearer tokens must have."
+}
+
+variable "jwt_audience" {
+  type = string
+  description = "The audience bearer tokens must have."
+}
+
+locals {
+  auth_environment = {
+    XEFFECT_JWKS_URL = var.jwks_url
+    XEFFECT_JWT_ISSUER = var.jwt_issuer
+    XEFFECT_JWT_AUDIENCE = var.jwt_audience
+  }
+}
+
 resource "aws_iam_role" "iam_for_lambda" {
   name = "iam_for_lambda"
   assume_role_policy = <<EOF
@@ -38,11 +62,15 @@ resource "aws_iam_role_policy" "lambda" {
          "dynamodb:Scan",
          "dynamodb:BatchWriteItem",
          "dynamodb:PutItem",
-         "dynamodb:UpdateItem"
+         "dynamodb:UpdateItem",
+         "dynamodb:DeleteItem"
         ]
         Resource = [
           aws_dynamodb_table.xeffect.arn,
-          "${aws_dynamodb_table.xeffect.arn}/*"
+          "${aws_dynamodb_table.xeffect.arn}/*",
+          aws_dynamodb_table.xeffect_api_keys.arn,
+          "${aws_dynamodb_table.xeffect_api_keys.arn}/*",
+          aws_dynamodb_table.xeffect_completion_events.arn
         ]
       }
     ]
@@ -66,6 +94,14 @@ resource "aws_api_gateway_rest_api" "api" {
       goal_get = aws_lambda_function.goal_get.invoke_arn
       goal_action = aws_lambda_function.goal_action.invoke_arn
       goal_get_completed = aws_lambda_function.goal_get_completed.invoke_arn
+      goal_update = aws_lambda_function.goal_update.invoke_arn
+      goal_delete = aws_lambda_function.goal_delete.invoke_arn
+      goal_get_events = aws_lambda_function.goal_get_events.invoke_arn
+      goal_get_card = aws_lambda_function.goal_get_card.invoke_arn
+      goal_print = aws_lambda_function.goal_print.invoke_arn
+      api_key_create = aws_lambda_function.api_key_create.invoke_arn
+      api_key_get_all = aws_lambda_function.api_key_get_all.invoke_arn
+      api_key_delete = aws_lambda_function.api_key_delete.invoke_arn
     }
   })
 }
@@ -149,3 +185,75 @@ resource "aws_lambda_permission" "goal_get_completed" {
 
   source_arn = "${aws_api_gateway_rest_api.api.execution_arn}/*/*/*"
 }
+
+resource "aws_lambda_permission" "goal_update" {
+  statement_id = "AllowExectionFromAPIGateway"
+  action = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.goal_update.function_name
+  principal = "apigateway.amazonaws.com"
+
+  source_arn = "${aws_api_gateway_rest_api.api.execution_arn}/*/*/*"
+}
+
+resource "aws_lambda_permission" "goal_delete" {
+  statement_id = "AllowExectionFromAPIGateway"
+  action = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.goal_delete.function_name
+  principal = "apigateway.amazonaws.com"
+
+  source_arn = "${aws_api_gateway_rest_api.api.execution_arn}/*/*/*"
+}
+
+resource "aws_lambda_permission" "goal_get_events" {
+  statement_id = "AllowExectionFromAPIGateway"
+  action = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.goal_get_events.function_name
+  principal = "apigateway.amazonaws.com"
+
+  source_arn = "${aws_api_gateway_rest_api.api.execution_arn}/*/*/*"
+}
+
+resource "aws_lambda_permission" "goal_get_card" {
+  statement_id = "AllowExectionFromAPIGateway"
+  action = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.goal_get_card.function_name
+  principal = "apigateway.amazonaws.com"
+
+  source_arn = "${aws_api_gateway_rest_api.api.execution_arn}/*/*/*"
+}
+
+resource "aws_lambda_permission" "goal_print" {
+  statement_id = "AllowExectionFromAPIGateway"
+  action = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.goal_print.function_name
+  principal = "apigateway.amazonaws.com"
+
+  source_arn = "${aws_api_gateway_rest_api.api.execution_arn}/*/*/*"
+}
+
+resource "aws_lambda_permission" "api_key_create" {
+  statement_id = "AllowExectionFromAPIGateway"
+  action = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.api_key_create.function_name
+  principal = "apigateway.amazonaws.com"
+
+  source_arn = "${aws_api_gateway_rest_api.api.execution_arn}/*/*/*"
+}
+
+resource "aws_lambda_permission" "api_key_get_all" {
+  statement_id = "AllowExectionFromAPIGateway"
+  action = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.api_key_get_all.function_name
+  principal = "apigateway.amazonaws.com"
+
+  source_arn = "${aws_api_gateway_rest_api.api.execution_arn}/*/*/*"
+}
+
+resource "aws_lambda_permission" "api_key_delete" {
+  statement_id = "AllowExectionFromAPIGateway"
+  action = "lambda:InvokeFunction"
+  function_name = aws_lambda_function.api_key_delete.function_name
+  principal = "apigateway.amazonaws.com"
+
+  source_arn = "${aws_api_gateway_rest_api.api.execution_arn}/*/*/*"
+}
@@ -0,0 +1,81 @@
+module github.com/maxstanley/xeffect_backend/local_server
+
+go 1.17
+
+require (
+	github.com/aws/aws-lambda-go v1.27.1
+	github.com/aws/aws-sdk-go-v2/config v1.11.1
+	github.com/aws/aws-sdk-go-v2/service/dynamodb v1.11.0
+	github.com/maxstanley/xeffect_backend/api_key_create v0.0.0
+	github.com/maxstanley/xeffect_backend/api_key_delete v0.0.0
+	github.com/maxstanley/xeffect_backend/api_key_get_all v0.0.0
+	github.com/maxstanley/xeffect_backend/goal_action v0.0.0
+	github.com/maxstanley/xeffect_backend/goal_create v0.0.0
+	github.com/maxstanley/xeffect_backend/goal_delete v0.0.0
+	github.com/maxstanley/xeffect_backend/goal_get v0.0.0
+	github.com/maxstanley/xeffect_backend/goal_get_all v0.0.0
+	github.com/maxstanley/xeffect_backend/goal_get_card v0.0.0
+	github.com/maxstanley/xeffect_backend/goal_get_completed v0.0.0
+	github.com/maxstanley/xeffect_backend/goal_get_events v0.0.0
+	github.com/maxstanley/xeffect_backend/goal_print v0.0.0
+	github.com/maxstanley/xeffect_backend/goal_update v0.0.0
+	github.com/maxstanley/xeffect_backend/version v0.0.0
+	github.com/maxstanley/xeffect_backend/xeffect v0.0.0
+)
+
+require (
+	github.com/aws/aws-sdk-go-v2 v1.11.2 // indirect
+	github.com/aws/aws-sdk-go-v2/credentials v1.6.5 // indirect
+	github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue v1.4.5 // indirect
+	github.com/aws/aws-sdk-go-v2/feature/ec2/imds v1.8.2 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/configsources v1.1.2 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/endpoints/v2 v2.0.2 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/ini v1.3.2 // indirect
+	github.com/aws/aws-sdk-go-v2/service/dynamodbstreams v1.9.0 // indirect
+	github.com/aws/aws-sdk-go-v2/service/internal/accept-encoding v1.5.0 // indirect
+	github.com/aws/aws-sdk-go-v2/service/internal/endpoint-discovery v1.3.3 // indirect
+	github.com/aws/aws-sdk-go-v2/service/internal/presigned-url v1.5.2 // indirect
+	github.com/aws/aws-sdk-go-v2/service/sso v1.7.0 // indirect
+	github.com/aws/aws-sdk-go-v2/service/sts v1.12.0 // indirect
+	github.com/aws/smithy-go v1.9.0 // indirect
+	github.com/go-playground/locales v0.14.0 // indirect
+	github.com/go-playground/universal-translator v0.18.0 // indirect
+	github.com/go-playground/validator/v10 v10.9.0 // indirect
+	github.com/google/uuid v1.3.0 // indirect
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	github.com/leodido/go-urn v1.2.1 // indirect
+	golang.org/x/crypto v0.0.0-20210711020723-a769d52b0f97 // indirect
+	golang.org/x/image v0.0.0-20211028202545-6944b10bf410 // indirect
+	golang.org/x/sys v0.0.0-20210806184541-e5e7981a1069 // indirect
+	golang.org/x/text v0.3.6 // indirect
+)
+
+replace github.com/maxstanley/xeffect_backend/xeffect => ../xeffect
+
+replace github.com/maxstanley/xeffect_backend/goal_action => ../goal_action
+
+replace github.com/maxstanley/xeffect_backend/goal_create => ../goal_create
+
+replace github.com/maxstanley/xeffect_backend/goal_delete => ../goal_delete
+
+replace github.com/maxstanley/xeffect_backend/goal_get => ../goal_get
+
+replace github.com/maxstanley/xeffect_backend/goal_get_all => ../goal_get_all
+
+replace github.com/maxstanley/xeffect_backend/goal_get_card => ../goal_get_card
+
+replace github.com/maxstanley/xeffect_backend/goal_get_completed => ../goal_get_completed
+
+replace github.com/maxstanley/xeffect_backend/goal_get_events => ../goal_get_events
+
+replace github.com/maxstanley/xeffect_backend/goal_print => ../goal_print
+
+replace github.com/maxstanley/xeffect_backend/goal_update => ../goal_update
+
+replace github.com/maxstanley/xeffect_backend/version => ../version
+
+replace github.com/maxstanley/xeffect_backend/api_key_create => ../api_key_create
+
+replace github.com/maxstanley/xeffect_backend/api_key_delete => ../api_key_delete
+
+replace github.com/maxstanley/xeffect_backend/api_key_get_all => ../api_key_get_all
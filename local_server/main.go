@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	apikeycreate "github.com/maxstanley/xeffect_backend/api_key_create/handler"
+	apikeydelete "github.com/maxstanley/xeffect_backend/api_key_delete/handler"
+	apikeygetall "github.com/maxstanley/xeffect_backend/api_key_get_all/handler"
+	goalaction "github.com/maxstanley/xeffect_backend/goal_action/handler"
+	goalcreate "github.com/maxstanley/xeffect_backend/goal_create/handler"
+	goaldelete "github.com/maxstanley/xeffect_backend/goal_delete/handler"
+	goalget "github.com/maxstanley/xeffect_backend/goal_get/handler"
+	goalgetall "github.com/maxstanley/xeffect_backend/goal_get_all/handler"
+	goalgetcard "github.com/maxstanley/xeffect_backend/goal_get_card/handler"
+	goalgetcompleted "github.com/maxstanley/xeffect_backend/goal_get_completed/handler"
+	goalgetevents "github.com/maxstanley/xeffect_backend/goal_get_events/handler"
+	goalprint "github.com/maxstanley/xeffect_backend/goal_print/handler"
+	goalupdate "github.com/maxstanley/xeffect_backend/goal_update/handler"
+	version "github.com/maxstanley/xeffect_backend/version/handler"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+	"github.com/maxstanley/xeffect_backend/xeffect/auth"
+)
+
+// BASE_PATH matches the stage in the server url of openapi.yaml, so clients
+// only need to swap the host.
+const BASE_PATH = "/v1"
+
+func newRepositories(storage string, endpoint string) (xeffect.GoalRepository, xeffect.APIKeyRepository) {
+	switch storage {
+	case "memory":
+		return xeffect.NewMemoryGoalRepository(), xeffect.NewMemoryAPIKeyRepository()
+	case "dynamodb":
+		cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion("eu-west-2"))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		var options []func(*dynamodb.Options)
+		if endpoint != "" {
+			options = append(options, dynamodb.WithEndpointResolver(dynamodb.EndpointResolverFromURL(endpoint)))
+		}
+
+		client := dynamodb.NewFromConfig(cfg, options...)
+
+		return xeffect.NewDynamoDBGoalRepository(client), xeffect.NewDynamoDBAPIKeyRepository(client)
+	}
+
+	log.Fatalf("'%s' is not a supported storage, use 'memory' or 'dynamodb'", storage)
+	return nil, nil
+}
+
+// authenticated wraps a lambda in the authenticator it is deployed with.
+func authenticated(authenticator *auth.Authenticator, lambdaHandler LambdaHandler) LambdaHandler {
+	return LambdaHandler(authenticator.Wrap(auth.Handler(lambdaHandler)))
+}
+
+func newRoutes(goals xeffect.GoalRepository, keys xeffect.APIKeyRepository, verifier *auth.Verifier) []Route {
+	goalAuthenticator := &auth.Authenticator{
+		Verifier: verifier,
+		APIKeys:  keys,
+	}
+	apiKeyAuthenticator := &auth.Authenticator{
+		Verifier: verifier,
+	}
+
+	apiKeyCreate := apikeycreate.New(keys)
+	apiKeyDelete := apikeydelete.New(keys)
+	apiKeyGetAll := apikeygetall.New(keys)
+	goalAction := goalaction.New(goals)
+	goalCreate := goalcreate.New(goals)
+	goalDelete := goaldelete.New(goals)
+	goalGet := goalget.New(goals)
+	goalGetAll := goalgetall.New(goals)
+	goalGetCard := goalgetcard.New(goals)
+	goalGetCompleted := goalgetcompleted.New(goals)
+	goalGetEvents := goalgetevents.New(goals)
+	goalPrint := goalprint.New(goals)
+	goalUpdate := goalupdate.New(goals)
+
+	goalUpdateHandler := func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		response, err := goalUpdate.HandleGoalUpdateEvent(ctx, goalupdate.Request(event))
+		return events.APIGatewayProxyResponse(response), err
+	}
+	goalGetCardHandler := func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		response, err := goalGetCard.HandleGoalGetCardEvent(ctx, goalgetcard.Request(event))
+		return events.APIGatewayProxyResponse(response), err
+	}
+	goalGetCompletedHandler := func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		response, err := goalGetCompleted.HandleGoalGetCompletedEvent(ctx, goalgetcompleted.Request(event))
+		return events.APIGatewayProxyResponse(response), err
+	}
+
+	return []Route{
+		{
+			Resource: "/xeffect/goals",
+			Methods: map[string]LambdaHandler{
+				http.MethodGet: authenticated(goalAuthenticator, func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+					response, err := goalGetAll.HandleGoalGetAllEvent(ctx, goalgetall.Request(event))
+					return events.APIGatewayProxyResponse(response), err
+				}),
+				http.MethodPost: authenticated(goalAuthenticator, func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+					response, err := goalCreate.HandleGoalCreationEvent(ctx, goalcreate.Request(event))
+					return events.APIGatewayProxyResponse(response), err
+				}),
+			},
+		},
+		{
+			Resource: "/xeffect/goals/{goalId}",
+			Methods: map[string]LambdaHandler{
+				http.MethodGet: authenticated(goalAuthenticator, func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+					response, err := goalGet.HandleGoalGetEvent(ctx, goalget.Request(event))
+					return events.APIGatewayProxyResponse(response), err
+				}),
+				http.MethodPost: authenticated(goalAuthenticator, func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+					response, err := goalAction.HandleGoalActionEvent(ctx, goalaction.Request(event))
+					return events.APIGatewayProxyResponse(response), err
+				}),
+				http.MethodPut:   authenticated(goalAuthenticator, goalUpdateHandler),
+				http.MethodPatch: authenticated(goalAuthenticator, goalUpdateHandler),
+				http.MethodDelete: authenticated(goalAuthenticator, func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+					response, err := goalDelete.HandleGoalDeleteEvent(ctx, goaldelete.Request(event))
+					return events.APIGatewayProxyResponse(response), err
+				}),
+			},
+		},
+		{
+			Resource: "/xeffect/goals/{goalId}/completed",
+			Methods: map[string]LambdaHandler{
+				http.MethodGet: authenticated(goalAuthenticator, goalGetCompletedHandler),
+			},
+		},
+		{
+			Resource: "/xeffect/goals/{goalId}/completed/{date}",
+			Methods: map[string]LambdaHandler{
+				http.MethodGet: authenticated(goalAuthenticator, goalGetCompletedHandler),
+			},
+		},
+		{
+			Resource: "/xeffect/goals/{goalId}/card",
+			Methods: map[string]LambdaHandler{
+				http.MethodGet: authenticated(goalAuthenticator, goalGetCardHandler),
+			},
+		},
+		{
+			Resource: "/xeffect/goals/{goalId}/card.svg",
+			Methods: map[string]LambdaHandler{
+				http.MethodGet: authenticated(goalAuthenticator, goalGetCardHandler),
+			},
+		},
+		{
+			Resource: "/xeffect/goals/{goalId}/card.png",
+			Methods: map[string]LambdaHandler{
+				http.MethodGet: authenticated(goalAuthenticator, goalGetCardHandler),
+			},
+		},
+		{
+			Resource: "/xeffect/goals/{goalId}/cards",
+			Methods: map[string]LambdaHandler{
+				http.MethodGet: authenticated(goalAuthenticator, goalGetCardHandler),
+			},
+		},
+		{
+			Resource: "/xeffect/goals/{goalId}/events",
+			Methods: map[string]LambdaHandler{
+				http.MethodGet: authenticated(goalAuthenticator, func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+					response, err := goalGetEvents.HandleGoalGetEventsEvent(ctx, goalgetevents.Request(event))
+					return events.APIGatewayProxyResponse(response), err
+				}),
+			},
+		},
+		{
+			Resource: "/xeffect/cards.pdf",
+			Methods: map[string]LambdaHandler{
+				http.MethodGet: authenticated(goalAuthenticator, func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+					response, err := goalPrint.HandleGoalPrintEvent(ctx, goalprint.Request(event))
+					return events.APIGatewayProxyResponse(response), err
+				}),
+			},
+		},
+		{
+			Resource: "/xeffect/api_keys",
+			Methods: map[string]LambdaHandler{
+				http.MethodGet: authenticated(apiKeyAuthenticator, func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+					response, err := apiKeyGetAll.HandleAPIKeyGetAllEvent(ctx, apikeygetall.Request(event))
+					return events.APIGatewayProxyResponse(response), err
+				}),
+				http.MethodPost: authenticated(apiKeyAuthenticator, func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+					response, err := apiKeyCreate.HandleAPIKeyCreationEvent(ctx, apikeycreate.Request(event))
+					return events.APIGatewayProxyResponse(response), err
+				}),
+			},
+		},
+		{
+			Resource: "/xeffect/api_keys/{keyId}",
+			Methods: map[string]LambdaHandler{
+				http.MethodDelete: authenticated(apiKeyAuthenticator, func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+					response, err := apiKeyDelete.HandleAPIKeyDeleteEvent(ctx, apikeydelete.Request(event))
+					return events.APIGatewayProxyResponse(response), err
+				}),
+			},
+		},
+		{
+			Resource: "/xeffect/version",
+			Methods: map[string]LambdaHandler{
+				http.MethodGet: func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+					response, err := version.HandleVersionEvent(ctx, version.Request(event))
+					return events.APIGatewayProxyResponse(response), err
+				},
+			},
+		},
+	}
+}
+
+// newVerifier returns a verifier for the key set given by either a file or a
+// url, or nil when neither is given.
+func newVerifier(jwksFile string, jwksURL string, issuer string, audience string) *auth.Verifier {
+	switch {
+	case jwksURL != "":
+		return auth.NewVerifier(auth.NewRemoteKeySet(jwksURL), issuer, audience)
+	case jwksFile != "":
+		keys, err := auth.NewFileKeySet(jwksFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return auth.NewVerifier(keys, issuer, audience)
+	}
+
+	return nil
+}
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	storage := flag.String("storage", "memory", "where goals are kept, 'memory' or 'dynamodb'")
+	endpoint := flag.String("dynamodb-endpoint", "", "DynamoDB endpoint url, such as a DynamoDB Local instance")
+	owner := flag.String("owner", "local", "the user every request is made as, unless bearer tokens are verified")
+	jwksFile := flag.String("jwks-file", "", "JWKS file to verify bearer tokens with")
+	jwksURL := flag.String("jwks-url", "", "JWKS url to verify bearer tokens with")
+	issuer := flag.String("jwt-issuer", "", "issuer bearer tokens must have")
+	audience := flag.String("jwt-audience", "", "audience bearer tokens must have")
+	flag.Parse()
+
+	goals, keys := newRepositories(*storage, *endpoint)
+	verifier := newVerifier(*jwksFile, *jwksURL, *issuer, *audience)
+
+	router := NewRouter(*owner, newRoutes(goals, keys, verifier)...)
+
+	mux := http.NewServeMux()
+	mux.Handle(BASE_PATH+"/", http.StripPrefix(BASE_PATH, router))
+
+	log.Printf("Serving the xeffect API on http://%s%s using %s storage", *addr, BASE_PATH, strings.ToLower(*storage))
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
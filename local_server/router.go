@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// LambdaHandler is the shape shared by every goal lambda once its own
+// Request and Response types have been converted.
+type LambdaHandler func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// Route maps the methods of an openapi.yaml resource, such as
+// "/xeffect/goals/{goalId}", to the lambdas that serve them.
+type Route struct {
+	Resource string
+	Methods  map[string]LambdaHandler
+}
+
+// Router serves Routes over net/http in the same way API Gateway invokes the
+// lambdas. Every request is made as owner, standing in for the authorizer.
+type Router struct {
+	owner  string
+	routes []Route
+}
+
+func NewRouter(owner string, routes ...Route) *Router {
+	return &Router{
+		owner:  owner,
+		routes: routes,
+	}
+}
+
+// matchResource returns the path parameters of path if it matches resource.
+func matchResource(resource string, path string) (map[string]string, bool) {
+	resourceSegments := strings.Split(strings.Trim(resource, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(resourceSegments) != len(pathSegments) {
+		return nil, false
+	}
+
+	parameters := map[string]string{}
+	for i, segment := range resourceSegments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			value, err := url.PathUnescape(pathSegments[i])
+			if err != nil || value == "" {
+				return nil, false
+			}
+			parameters[segment[1:len(segment)-1]] = value
+			continue
+		}
+
+		if segment != pathSegments[i] {
+			return nil, false
+		}
+	}
+
+	return parameters, true
+}
+
+// newProxyRequest translates r into the event API Gateway would have sent.
+func newProxyRequest(r *http.Request, resource string, pathParameters map[string]string, owner string) (events.APIGatewayProxyRequest, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return events.APIGatewayProxyRequest{}, err
+	}
+
+	headers := map[string]string{}
+	multiValueHeaders := map[string][]string{}
+	for name, values := range r.Header {
+		name = strings.ToLower(name)
+		headers[name] = values[len(values)-1]
+		multiValueHeaders[name] = values
+	}
+
+	queryStringParameters := map[string]string{}
+	multiValueQueryStringParameters := map[string][]string{}
+	for name, values := range r.URL.Query() {
+		queryStringParameters[name] = values[len(values)-1]
+		multiValueQueryStringParameters[name] = values
+	}
+
+	return events.APIGatewayProxyRequest{
+		Resource:                        resource,
+		Path:                            r.URL.Path,
+		HTTPMethod:                      r.Method,
+		Headers:                         headers,
+		MultiValueHeaders:               multiValueHeaders,
+		QueryStringParameters:           queryStringParameters,
+		MultiValueQueryStringParameters: multiValueQueryStringParameters,
+		PathParameters:                  pathParameters,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Stage:        "local",
+			ResourcePath: resource,
+			HTTPMethod:   r.Method,
+			Authorizer: map[string]interface{}{
+				"principalId": owner,
+			},
+		},
+		Body:            string(body),
+		IsBase64Encoded: false,
+	}, nil
+}
+
+func writeProxyResponse(w http.ResponseWriter, response events.APIGatewayProxyResponse) {
+	for name, value := range response.Headers {
+		w.Header().Set(name, value)
+	}
+	for name, values := range response.MultiValueHeaders {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+
+	body := []byte(response.Body)
+	if response.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(response.Body)
+		if err != nil {
+			log.Printf("invalid base64 response body: %v", err)
+			http.Error(w, "Internal server error", http.StatusBadGateway)
+			return
+		}
+		body = decoded
+	}
+
+	w.WriteHeader(response.StatusCode)
+	w.Write(body)
+}
+
+// writeCORS mirrors the mock integration behind every OPTIONS method in
+// openapi.yaml.
+func writeCORS(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Headers", "*")
+	w.Header().Set("Access-Control-Allow-Methods", "*")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (router *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, route := range router.routes {
+		pathParameters, ok := matchResource(route.Resource, r.URL.Path)
+		if !ok {
+			continue
+		}
+
+		if r.Method == http.MethodOptions {
+			writeCORS(w)
+			return
+		}
+
+		lambdaHandler, ok := route.Methods[r.Method]
+		if !ok {
+			// API Gateway answers unconfigured methods as missing
+			// authentication tokens, which is of no help locally.
+			http.Error(w, fmt.Sprintf("Method %s is not supported on %s", r.Method, route.Resource), http.StatusMethodNotAllowed)
+			return
+		}
+
+		if len(pathParameters) == 0 {
+			pathParameters = nil
+		}
+		event, err := newProxyRequest(r, route.Resource, pathParameters, router.owner)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		response, err := lambdaHandler(r.Context(), event)
+		if err != nil {
+			// A lambda error surfaces from API Gateway as a 502.
+			log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+			http.Error(w, "Internal server error", http.StatusBadGateway)
+			return
+		}
+
+		log.Printf("%s %s %d", r.Method, r.URL.Path, response.StatusCode)
+		writeProxyResponse(w, response)
+		return
+	}
+
+	http.NotFound(w, r)
+}
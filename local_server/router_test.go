@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+func TestMatchResource(t *testing.T) {
+	tests := []struct {
+		resource   string
+		path       string
+		parameters map[string]string
+		ok         bool
+	}{
+		{resource: "/xeffect/goals", path: "/xeffect/goals", parameters: map[string]string{}, ok: true},
+		{resource: "/xeffect/goals", path: "/xeffect/goals/", parameters: map[string]string{}, ok: true},
+		{resource: "/xeffect/goals/{goalId}", path: "/xeffect/goals/goal-1", parameters: map[string]string{"goalId": "goal-1"}, ok: true},
+		{resource: "/xeffect/goals/{goalId}", path: "/xeffect/goals/goal%201", parameters: map[string]string{"goalId": "goal 1"}, ok: true},
+		{resource: "/xeffect/goals/{goalId}/completed/{date}", path: "/xeffect/goals/goal-1/completed/2021-12-01", parameters: map[string]string{"goalId": "goal-1", "date": "2021-12-01"}, ok: true},
+		{resource: "/xeffect/goals/{goalId}/card.svg", path: "/xeffect/goals/goal-1/card.svg", parameters: map[string]string{"goalId": "goal-1"}, ok: true},
+		{resource: "/xeffect/goals/{goalId}/card", path: "/xeffect/goals/goal-1/card.svg"},
+		{resource: "/xeffect/goals/{goalId}", path: "/xeffect/goals"},
+		{resource: "/xeffect/goals/{goalId}", path: "/xeffect/goals/goal-1/card"},
+		{resource: "/xeffect/goals/{goalId}/completed", path: "/xeffect/goals//completed"},
+		{resource: "/xeffect/goals", path: "/xeffect/api_keys"},
+		{resource: "/xeffect/goals/{goalId}", path: "/xeffect/goals/%zz"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.resource+" "+test.path, func(t *testing.T) {
+			parameters, ok := matchResource(test.resource, test.path)
+			if ok != test.ok {
+				t.Fatalf("the path matches %v, want %v", ok, test.ok)
+			}
+
+			if ok && !reflect.DeepEqual(parameters, test.parameters) {
+				t.Errorf("the path parameters are %v, want %v", parameters, test.parameters)
+			}
+		})
+	}
+}
+
+// recordingHandler answers every request with the method, resource and goal
+// of the event it was given, as seen by the lambda.
+func recordingHandler(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	owner, _ := xeffect.RequestOwner(event.RequestContext)
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers:    map[string]string{"Content-Type": "text/plain"},
+		Body:       strings.Join([]string{event.HTTPMethod, event.Resource, event.PathParameters["goalId"], event.QueryStringParameters["date"], owner}, " "),
+	}, nil
+}
+
+func TestRouter(t *testing.T) {
+	router := NewRouter("owner-1",
+		Route{
+			Resource: "/xeffect/goals",
+			Methods: map[string]LambdaHandler{
+				http.MethodGet: recordingHandler,
+			},
+		},
+		Route{
+			Resource: "/xeffect/goals/{goalId}",
+			Methods: map[string]LambdaHandler{
+				http.MethodGet:   recordingHandler,
+				http.MethodPatch: recordingHandler,
+				http.MethodDelete: func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+					return events.APIGatewayProxyResponse{}, errors.New("the lambda failed")
+				},
+			},
+		},
+		Route{
+			Resource: "/xeffect/goals/{goalId}/card.png",
+			Methods: map[string]LambdaHandler{
+				http.MethodGet: func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+					return events.APIGatewayProxyResponse{
+						StatusCode:      200,
+						IsBase64Encoded: true,
+						Headers:         map[string]string{"Content-Type": "image/png"},
+						Body:            base64.StdEncoding.EncodeToString([]byte("\x89PNG")),
+					}, nil
+				},
+			},
+		},
+	)
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		status      int
+		body        string
+		contentType string
+	}{
+		{
+			name:   "a resource without parameters",
+			method: http.MethodGet,
+			target: "/xeffect/goals?date=2021-12-01",
+			status: 200,
+			body:   "GET /xeffect/goals  2021-12-01 owner-1",
+		},
+		{
+			name:   "a resource with a parameter",
+			method: http.MethodGet,
+			target: "/xeffect/goals/goal-1",
+			status: 200,
+			body:   "GET /xeffect/goals/{goalId} goal-1  owner-1",
+		},
+		{
+			name:   "another method of the same resource",
+			method: http.MethodPatch,
+			target: "/xeffect/goals/goal-1",
+			status: 200,
+			body:   "PATCH /xeffect/goals/{goalId} goal-1  owner-1",
+		},
+		{
+			name:   "a method the resource does not have",
+			method: http.MethodPost,
+			target: "/xeffect/goals/goal-1",
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "a lambda error",
+			method: http.MethodDelete,
+			target: "/xeffect/goals/goal-1",
+			status: http.StatusBadGateway,
+		},
+		{
+			name:        "a base64 encoded response",
+			method:      http.MethodGet,
+			target:      "/xeffect/goals/goal-1/card.png",
+			status:      200,
+			body:        "\x89PNG",
+			contentType: "image/png",
+		},
+		{
+			name:   "an unknown path",
+			method: http.MethodGet,
+			target: "/xeffect/goals/goal-1/card.svg",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, httptest.NewRequest(test.method, test.target, nil))
+
+			if recorder.Code != test.status {
+				t.Fatalf("%s %s was answered with %d, want %d: %s", test.method, test.target, recorder.Code, test.status, recorder.Body.String())
+			}
+
+			if test.body != "" && recorder.Body.String() != test.body {
+				t.Errorf("the body is %q, want %q", recorder.Body.String(), test.body)
+			}
+
+			if test.contentType != "" && recorder.Header().Get("Content-Type") != test.contentType {
+				t.Errorf("the Content-Type is '%s', want '%s'", recorder.Header().Get("Content-Type"), test.contentType)
+			}
+		})
+	}
+}
+
+func TestRouterPreflight(t *testing.T) {
+	router := NewRouter("owner-1", Route{
+		Resource: "/xeffect/goals/{goalId}/events",
+		Methods: map[string]LambdaHandler{
+			http.MethodGet: func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				t.Error("a preflight request was passed to the lambda")
+				return events.APIGatewayProxyResponse{}, nil
+			},
+		},
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodOptions, "/xeffect/goals/goal-1/events", nil))
+
+	if recorder.Code != 200 {
+		t.Fatalf("the preflight was answered with %d, want 200", recorder.Code)
+	}
+
+	for _, header := range []string{"Access-Control-Allow-Headers", "Access-Control-Allow-Methods", "Access-Control-Allow-Origin"} {
+		if value := recorder.Header().Get(header); value != "*" {
+			t.Errorf("%s is '%s', want '*'", header, value)
+		}
+	}
+}
+
+// openAPIMethods reads the methods of every path in openapi.yaml, other than
+// the OPTIONS methods answered by the router itself.
+func openAPIMethods(t *testing.T) map[string][]string {
+	t.Helper()
+
+	file, err := os.Open("../openapi.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	methods := map[string][]string{}
+	inPaths := false
+	path := ""
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " ")
+		switch {
+		case line == "paths:":
+			inPaths = true
+		case inPaths && line != "" && !strings.HasPrefix(line, " "):
+			inPaths = false
+		case inPaths && strings.HasPrefix(line, "  /") && strings.HasSuffix(line, ":"):
+			path = strings.TrimSuffix(strings.TrimSpace(line), ":")
+			methods[path] = []string{}
+		case inPaths && path != "" && strings.HasPrefix(line, "    ") && !strings.HasPrefix(line, "     ") && strings.HasSuffix(line, ":"):
+			if method := strings.ToUpper(strings.TrimSuffix(strings.TrimSpace(line), ":")); method != http.MethodOptions {
+				methods[path] = append(methods[path], method)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return methods
+}
+
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	routes := map[string]Route{}
+	for _, route := range newRoutes(xeffect.NewMemoryGoalRepository(), xeffect.NewMemoryAPIKeyRepository(), nil) {
+		routes[route.Resource] = route
+	}
+
+	methods := openAPIMethods(t)
+	if len(methods) == 0 {
+		t.Fatal("no paths were read from openapi.yaml")
+	}
+
+	for path, pathMethods := range methods {
+		route, ok := routes[path]
+		if !ok {
+			t.Errorf("%s has no route", path)
+			continue
+		}
+
+		for _, method := range pathMethods {
+			if _, ok := route.Methods[method]; !ok {
+				t.Errorf("%s %s has no route", method, path)
+			}
+		}
+
+		if len(route.Methods) != len(pathMethods) {
+			t.Errorf("%s is routed for %d methods, want the %d of openapi.yaml", path, len(route.Methods), len(pathMethods))
+		}
+	}
+
+	if len(routes) != len(methods) {
+		t.Errorf("%d resources are routed, want the %d of openapi.yaml", len(routes), len(methods))
+	}
+}
+
+func TestRoutesServeGoals(t *testing.T) {
+	router := NewRouter("owner-1", newRoutes(xeffect.NewMemoryGoalRepository(), xeffect.NewMemoryAPIKeyRepository(), nil)...)
+
+	request := httptest.NewRequest(http.MethodPost, "/xeffect/goals", strings.NewReader(`{"title": "Read", "motivation": "Learn"}`))
+	request.Header.Set("Content-Type", "application/json")
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	if recorder.Code != 201 {
+		t.Fatalf("creating a goal was answered with %d, want 201: %s", recorder.Code, recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/xeffect/goals", nil))
+
+	body, err := io.ReadAll(recorder.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if recorder.Code != 200 || !strings.Contains(string(body), `"title":"Read"`) {
+		t.Errorf("listing the goals was answered with %d: %s", recorder.Code, body)
+	}
+}
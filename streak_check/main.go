@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+// PAGE_SIZE is the number of goals read from the table at a time.
+const PAGE_SIZE = 100
+
+// Report counts the goals seen by a check of the goals table.
+type Report struct {
+	Checked      int
+	Inconsistent int
+	Repaired     int
+	Failed       int
+}
+
+// completionEvents reads every completion event of a goal.
+func completionEvents(ctx context.Context, goals xeffect.GoalRepository, id string) ([]xeffect.CompletionEvent, error) {
+	events := []xeffect.CompletionEvent{}
+
+	query := xeffect.CompletionEventQuery{
+		PageSize: PAGE_SIZE,
+	}
+	for {
+		page, err := goals.ListCompletionEvents(ctx, id, query)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, page.Events...)
+
+		if page.NextPageToken == "" {
+			return events, nil
+		}
+		query.PageToken = page.NextPageToken
+	}
+}
+
+// compareDays describes every day on which the streaks of a goal differ from
+// its event log.
+func compareDays(days map[string]bool, logged map[string]bool) []string {
+	dates := []string{}
+	for date := range days {
+		if !logged[date] {
+			dates = append(dates, date)
+		}
+	}
+	for date := range logged {
+		if !days[date] {
+			dates = append(dates, date)
+		}
+	}
+	sort.Strings(dates)
+
+	issues := []string{}
+	for _, date := range dates {
+		if days[date] {
+			issues = append(issues, fmt.Sprintf("'%s' is completed, but is not completed in the event log", date))
+		} else {
+			issues = append(issues, fmt.Sprintf("'%s' is not completed, but is completed in the event log", date))
+		}
+	}
+
+	return issues
+}
+
+// checkGoal reports the issues with the streaks of a goal, and when repair is
+// set, replaces its streaks with a consistent layout of the same days. When
+// fromEvents is set, a goal with an event log is also checked against it, and
+// repaired to the days replayed from it.
+func checkGoal(ctx context.Context, goals xeffect.GoalRepository, goal xeffect.Goal, repair bool, fromEvents bool, report *Report) {
+	report.Checked++
+
+	issues := xeffect.CheckStreaks(goal)
+
+	var logged map[string]bool
+	if fromEvents && goal.EventCount > 0 {
+		events, err := completionEvents(ctx, goals, goal.Uuid)
+		if err != nil {
+			fmt.Printf("goal %s (%s):\n  events can not be read: %v\n", goal.Uuid, goal.Title, err)
+			report.Failed++
+			return
+		}
+		logged = xeffect.ReplayCompletionEvents(events)
+
+		// Streaks which can not be read are already reported by CheckStreaks.
+		if days, err := xeffect.CompletedDays(goal); err == nil {
+			issues = append(issues, compareDays(days, logged)...)
+		}
+	}
+
+	if len(issues) == 0 {
+		return
+	}
+	report.Inconsistent++
+
+	fmt.Printf("goal %s (%s):\n", goal.Uuid, goal.Title)
+	for _, issue := range issues {
+		fmt.Printf("  %s\n", issue)
+	}
+
+	var (
+		update xeffect.StreakUpdate
+		err    error
+	)
+	if logged != nil {
+		update, err = xeffect.RebuildStreaks(goal, logged)
+	} else {
+		update, err = xeffect.NormaliseStreaks(goal)
+	}
+	if err != nil {
+		fmt.Printf("  can not be repaired: %v\n", err)
+		report.Failed++
+		return
+	}
+
+	if !repair {
+		fmt.Printf("  would be repaired to %d streaks, starting %v, with a best streak of %d\n", len(update.StreakDates), update.StreakDates, update.BestStreak)
+		return
+	}
+
+	// The repair is only made if the goal is unchanged since it was checked, so
+	// that a completion made in the meantime is never lost.
+	err = goals.UpdateStreaks(ctx, goal.Uuid, update)
+	if errors.Is(err, xeffect.ErrVersionConflict) || errors.Is(err, xeffect.ErrGoalNotFound) {
+		fmt.Printf("  not repaired, as the goal changed while it was checked\n")
+		report.Failed++
+		return
+	}
+
+	if err != nil {
+		fmt.Printf("  not repaired: %v\n", err)
+		report.Failed++
+		return
+	}
+
+	fmt.Printf("  repaired to %d streaks, starting %v, with a best streak of %d\n", len(update.StreakDates), update.StreakDates, update.BestStreak)
+	report.Repaired++
+}
+
+// checkGoals checks every goal of every owner, including archived goals.
+func checkGoals(ctx context.Context, goals xeffect.GoalRepository, repair bool, fromEvents bool) (Report, error) {
+	report := Report{}
+
+	query := xeffect.GoalQuery{
+		IncludeArchived: true,
+		PageSize:        PAGE_SIZE,
+	}
+	for {
+		page, err := goals.ListGoals(ctx, query)
+		if err != nil {
+			return report, err
+		}
+
+		for _, goal := range page.Goals {
+			checkGoal(ctx, goals, goal, repair, fromEvents, &report)
+		}
+
+		if page.NextPageToken == "" {
+			return report, nil
+		}
+		query.PageToken = page.NextPageToken
+	}
+}
+
+// assignOwners gives every goal which has no owner to owner, returning the
+// number of goals assigned.
+func assignOwners(ctx context.Context, goals *xeffect.DynamoDBGoalRepository, owner string) (int, error) {
+	assigned := 0
+
+	query := xeffect.GoalQuery{
+		IncludeArchived: true,
+		PageSize:        PAGE_SIZE,
+	}
+	for {
+		page, err := goals.ListGoals(ctx, query)
+		if err != nil {
+			return assigned, err
+		}
+
+		for _, goal := range page.Goals {
+			if goal.Owner != "" {
+				continue
+			}
+
+			err := goals.AssignOwner(ctx, goal.Uuid, owner)
+			if errors.Is(err, xeffect.ErrVersionConflict) || errors.Is(err, xeffect.ErrGoalNotFound) {
+				fmt.Printf("goal %s (%s): not assigned, as the goal changed while it was read\n", goal.Uuid, goal.Title)
+				continue
+			}
+
+			if err != nil {
+				return assigned, err
+			}
+
+			fmt.Printf("goal %s (%s): assigned to '%s'\n", goal.Uuid, goal.Title, owner)
+			assigned++
+		}
+
+		if page.NextPageToken == "" {
+			return assigned, nil
+		}
+		query.PageToken = page.NextPageToken
+	}
+}
+
+func main() {
+	repair := flag.Bool("repair", false, "replace inconsistent streaks with a consistent layout, rather than only reporting them")
+	fromEvents := flag.Bool("events", false, "also check goals against their completion events, and repair them to the days the events give")
+	assignOwner := flag.String("assign-owner", "", "give every goal without an owner to this owner, such as the goals written before goals had owners, instead of checking streaks")
+	endpoint := flag.String("dynamodb-endpoint", "", "DynamoDB endpoint url, such as a DynamoDB Local instance")
+	flag.Parse()
+
+	cfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion("eu-west-2"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var options []func(*dynamodb.Options)
+	if *endpoint != "" {
+		options = append(options, dynamodb.WithEndpointResolver(dynamodb.EndpointResolverFromURL(*endpoint)))
+	}
+
+	goals := xeffect.NewDynamoDBGoalRepository(dynamodb.NewFromConfig(cfg, options...))
+
+	if *assignOwner != "" {
+		assigned, err := assignOwners(context.Background(), goals, *assignOwner)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("%d goals assigned to '%s'\n", assigned, *assignOwner)
+		return
+	}
+
+	report, err := checkGoals(context.Background(), goals, *repair, *fromEvents)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%d goals checked, %d inconsistent", report.Checked, report.Inconsistent)
+	if *repair {
+		fmt.Printf(", %d repaired", report.Repaired)
+	}
+	if report.Failed > 0 {
+		fmt.Printf(", %d could not be repaired", report.Failed)
+	}
+	fmt.Println()
+
+	// A dry run exits with an error when any goal is inconsistent, so that it can
+	// be used as a check.
+	if report.Failed > 0 || (!*repair && report.Inconsistent > 0) {
+		os.Exit(1)
+	}
+}
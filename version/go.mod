@@ -2,4 +2,4 @@ module github.com/maxstanley/xeffect_backend/version
 
 go 1.17
 
-require github.com/aws/aws-lambda-go v1.27.1 // indirect
+require github.com/aws/aws-lambda-go v1.27.1
@@ -1,27 +1,10 @@
 package main
 
 import (
-	"context"
-
-	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/maxstanley/xeffect_backend/version/handler"
 )
 
-type Request events.APIGatewayProxyRequest
-type Response events.APIGatewayProxyResponse
-
-func handleVersionEvent(ctx context.Context, event Request) (Response, error) {
-	return Response{
-		StatusCode:      200,
-		IsBase64Encoded: false,
-		Headers: map[string]string{
-			"Content-Type":                "text/plain",
-			"Access-Control-Allow-Origin": "*",
-		},
-		Body: "0.2.0",
-	}, nil
-}
-
 func main() {
-	lambda.Start(handleVersionEvent)
+	lambda.Start(handler.HandleVersionEvent)
 }
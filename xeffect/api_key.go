@@ -0,0 +1,114 @@
+package xeffect
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// API_KEY_PREFIX starts every API key, so that keys can be told apart from
+// other bearer tokens.
+const API_KEY_PREFIX = "xek_"
+
+// The scopes an API key can be limited to. A key without any scopes can make
+// any request its owner can.
+const (
+	// API_KEY_SCOPE_READ allows every GET request.
+	API_KEY_SCOPE_READ = "read"
+	// API_KEY_SCOPE_MARK_COMPLETED allows the mark_completed and
+	// mark_completed_bulk goal actions, and undoing them.
+	API_KEY_SCOPE_MARK_COMPLETED = "mark_completed"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidAPIKey  = errors.New("invalid api key")
+)
+
+// APIKey is a key a user has created for scripts to make requests as them. Only
+// a hash of the secret part of the key is stored.
+type APIKey struct {
+	Uuid      string   `json:"uuid" dynamodbav:"uuid"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	CreatedAt string   `json:"created_at"`
+	Owner     string   `json:"-" dynamodbav:",omitempty"`
+	Hash      string   `json:"-"`
+}
+
+// APIKeyRepository stores API keys. Like a GoalRepository, a repository
+// returned by ForOwner only sees the keys of that owner, and gives the keys it
+// creates to the owner.
+type APIKeyRepository interface {
+	ForOwner(owner string) APIKeyRepository
+	CreateAPIKey(ctx context.Context, key APIKey) error
+	GetAPIKey(ctx context.Context, id string) (APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]APIKey, error)
+	DeleteAPIKey(ctx context.Context, id string) error
+}
+
+func hashAPIKeySecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// NewAPIKey creates a key, returning the key to be stored along with the key to
+// be given to the user, which is never seen again.
+func NewAPIKey(name string, scopes []string) (APIKey, string, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return APIKey{}, "", err
+	}
+	secret := base64.RawURLEncoding.EncodeToString(random)
+
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	key := APIKey{
+		Uuid:   uuid.New().String(),
+		Name:   name,
+		Scopes: scopes,
+		Hash:   hashAPIKeySecret(secret),
+	}
+
+	return key, API_KEY_PREFIX + key.Uuid + "_" + secret, nil
+}
+
+// IsAPIKey reports whether token looks like an API key rather than any other
+// bearer token.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, API_KEY_PREFIX)
+}
+
+// ParseAPIKey splits an API key into the id of the stored key and its secret.
+func ParseAPIKey(token string) (string, string, error) {
+	if !IsAPIKey(token) {
+		return "", "", ErrInvalidAPIKey
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(token, API_KEY_PREFIX), "_", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", ErrInvalidAPIKey
+	}
+
+	return parts[0], parts[1], nil
+}
+
+// Matches reports whether secret is the secret of the key.
+func (key APIKey) Matches(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(key.Hash)) == 1
+}
+
+// copyAPIKey returns a copy of key which shares no slices with it.
+func copyAPIKey(key APIKey) APIKey {
+	key.Scopes = append([]string{}, key.Scopes...)
+	return key
+}
@@ -0,0 +1,177 @@
+package xeffect
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const API_KEY_TABLE = "xeffect_api_keys"
+
+// API_KEY_OWNER_INDEX is the index of the API keys table keyed by the owner of
+// each key.
+const API_KEY_OWNER_INDEX = "owner_index"
+
+// DynamoDBAPIKeyRepository stores API keys in the API keys table, with one item
+// per key keyed by its uuid.
+type DynamoDBAPIKeyRepository struct {
+	client *dynamodb.Client
+	table  string
+	owner  string
+}
+
+func NewDynamoDBAPIKeyRepository(client *dynamodb.Client) *DynamoDBAPIKeyRepository {
+	return &DynamoDBAPIKeyRepository{
+		client: client,
+		table:  API_KEY_TABLE,
+	}
+}
+
+func (r *DynamoDBAPIKeyRepository) ForOwner(owner string) APIKeyRepository {
+	return &DynamoDBAPIKeyRepository{
+		client: r.client,
+		table:  r.table,
+		owner:  owner,
+	}
+}
+
+func (r *DynamoDBAPIKeyRepository) key(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"uuid": &types.AttributeValueMemberS{
+			Value: id,
+		},
+	}
+}
+
+func (r *DynamoDBAPIKeyRepository) CreateAPIKey(ctx context.Context, key APIKey) error {
+	if r.owner != "" {
+		key.Owner = r.owner
+	}
+
+	item, err := attributevalue.MarshalMap(key)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: aws.String(r.table),
+	}
+
+	_, err = r.client.PutItem(ctx, input)
+
+	return err
+}
+
+func (r *DynamoDBAPIKeyRepository) GetAPIKey(ctx context.Context, id string) (APIKey, error) {
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(r.table),
+		Key:       r.key(id),
+	}
+
+	result, err := r.client.GetItem(ctx, input)
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	if len(result.Item) == 0 {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+
+	var key APIKey
+	if err := attributevalue.UnmarshalMap(result.Item, &key); err != nil {
+		return APIKey{}, err
+	}
+
+	if r.owner != "" && key.Owner != r.owner {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+
+	return key, nil
+}
+
+// ListAPIKeys reads every key of the owner from the owner index, or scans the
+// whole table when the repository has no owner.
+func (r *DynamoDBAPIKeyRepository) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	keys := []APIKey{}
+
+	var startKey map[string]types.AttributeValue
+	for {
+		var items []map[string]types.AttributeValue
+		if r.owner == "" {
+			result, err := r.client.Scan(ctx, &dynamodb.ScanInput{
+				TableName:         aws.String(r.table),
+				ExclusiveStartKey: startKey,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			items, startKey = result.Items, result.LastEvaluatedKey
+		} else {
+			result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+				TableName:              aws.String(r.table),
+				IndexName:              aws.String(API_KEY_OWNER_INDEX),
+				KeyConditionExpression: aws.String("#owner = :owner"),
+				ExclusiveStartKey:      startKey,
+				ExpressionAttributeNames: map[string]string{
+					"#owner": "Owner",
+				},
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":owner": &types.AttributeValueMemberS{
+						Value: r.owner,
+					},
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			items, startKey = result.Items, result.LastEvaluatedKey
+		}
+
+		page := []APIKey{}
+		if err := attributevalue.UnmarshalListOfMaps(items, &page); err != nil {
+			return nil, err
+		}
+		keys = append(keys, page...)
+
+		if len(startKey) == 0 {
+			return keys, nil
+		}
+	}
+}
+
+func (r *DynamoDBAPIKeyRepository) DeleteAPIKey(ctx context.Context, id string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName:           aws.String(r.table),
+		Key:                 r.key(id),
+		ConditionExpression: aws.String("attribute_exists(#uuid)"),
+		ExpressionAttributeNames: map[string]string{
+			"#uuid": "uuid",
+		},
+	}
+
+	if r.owner != "" {
+		input.ConditionExpression = aws.String("attribute_exists(#uuid) AND #owner = :owner")
+		input.ExpressionAttributeNames["#owner"] = "Owner"
+		input.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":owner": &types.AttributeValueMemberS{
+				Value: r.owner,
+			},
+		}
+	}
+
+	_, err := r.client.DeleteItem(ctx, input)
+
+	var conditionalCheckFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionalCheckFailed) {
+		return ErrAPIKeyNotFound
+	}
+
+	return err
+}
@@ -0,0 +1,98 @@
+package xeffect
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// MemoryAPIKeyRepository stores API keys in memory, and is safe for concurrent
+// use.
+type MemoryAPIKeyRepository struct {
+	mu    *sync.Mutex
+	keys  map[string]APIKey
+	owner string
+}
+
+func NewMemoryAPIKeyRepository() *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{
+		mu:   &sync.Mutex{},
+		keys: map[string]APIKey{},
+	}
+}
+
+// ForOwner returns a repository sharing the keys of r.
+func (r *MemoryAPIKeyRepository) ForOwner(owner string) APIKeyRepository {
+	return &MemoryAPIKeyRepository{
+		mu:    r.mu,
+		keys:  r.keys,
+		owner: owner,
+	}
+}
+
+// key returns the key with the given id, if it can be seen by the owner of the
+// repository. The lock must be held.
+func (r *MemoryAPIKeyRepository) key(id string) (APIKey, bool) {
+	key, ok := r.keys[id]
+	if !ok || (r.owner != "" && key.Owner != r.owner) {
+		return APIKey{}, false
+	}
+
+	return key, true
+}
+
+func (r *MemoryAPIKeyRepository) CreateAPIKey(ctx context.Context, key APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.owner != "" {
+		key.Owner = r.owner
+	}
+	r.keys[key.Uuid] = copyAPIKey(key)
+
+	return nil
+}
+
+func (r *MemoryAPIKeyRepository) GetAPIKey(ctx context.Context, id string) (APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key, ok := r.key(id)
+	if !ok {
+		return APIKey{}, ErrAPIKeyNotFound
+	}
+
+	return copyAPIKey(key), nil
+}
+
+// ListAPIKeys lists the keys ordered by uuid.
+func (r *MemoryAPIKeyRepository) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := []APIKey{}
+	for id := range r.keys {
+		if key, ok := r.key(id); ok {
+			keys = append(keys, copyAPIKey(key))
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Uuid < keys[j].Uuid
+	})
+
+	return keys, nil
+}
+
+func (r *MemoryAPIKeyRepository) DeleteAPIKey(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.key(id); !ok {
+		return ErrAPIKeyNotFound
+	}
+
+	delete(r.keys, id)
+
+	return nil
+}
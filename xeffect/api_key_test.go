@@ -0,0 +1,153 @@
+package xeffect
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	key, token, err := NewAPIKey("script", []string{API_KEY_SCOPE_READ})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsAPIKey(token) {
+		t.Fatalf("'%s' does not start with '%s'", token, API_KEY_PREFIX)
+	}
+
+	id, secret, err := ParseAPIKey(token)
+	if err != nil {
+		t.Fatalf("ParseAPIKey returned %v for a new key", err)
+	}
+
+	if id != key.Uuid {
+		t.Errorf("the key id is '%s', want '%s'", id, key.Uuid)
+	}
+
+	if strings.Contains(key.Hash, secret) {
+		t.Error("the stored hash contains the secret")
+	}
+
+	if !key.Matches(secret) {
+		t.Error("the key does not match its own secret")
+	}
+
+	_, other, err := NewAPIKey("other", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherSecret, err := ParseAPIKey(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.Matches(otherSecret) {
+		t.Error("the key matches the secret of another key")
+	}
+
+	if key.Matches(secret + "x") {
+		t.Error("the key matches a longer secret")
+	}
+}
+
+func TestParseAPIKey(t *testing.T) {
+	tests := []struct {
+		token  string
+		id     string
+		secret string
+		valid  bool
+	}{
+		{"xek_1234_secret", "1234", "secret", true},
+		{"xek_1234_secret_with_underscores", "1234", "secret_with_underscores", true},
+		{"xek_", "", "", false},
+		{"xek_1234", "", "", false},
+		{"xek_1234_", "", "", false},
+		{"xek__secret", "", "", false},
+		{"xek1234_secret", "", "", false},
+		{"XEK_1234_secret", "", "", false},
+		{"eyJhbGciOiJSUzI1NiJ9.e30.c2ln", "", "", false},
+		{"", "", "", false},
+	}
+
+	for _, test := range tests {
+		id, secret, err := ParseAPIKey(test.token)
+		if !test.valid {
+			if !errors.Is(err, ErrInvalidAPIKey) {
+				t.Errorf("ParseAPIKey(%q) returned %v, want ErrInvalidAPIKey", test.token, err)
+			}
+
+			continue
+		}
+
+		if err != nil || id != test.id || secret != test.secret {
+			t.Errorf("ParseAPIKey(%q) = %q, %q, %v, want %q, %q", test.token, id, secret, err, test.id, test.secret)
+		}
+	}
+}
+
+func TestMemoryAPIKeyRepositoryForOwner(t *testing.T) {
+	ctx := context.Background()
+	repository := NewMemoryAPIKeyRepository()
+	alice := repository.ForOwner("alice")
+	bob := repository.ForOwner("bob")
+
+	aliceKey, _, err := NewAPIKey("alice's script", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bobKey, _, err := NewAPIKey("bob's script", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The owner given to the key is replaced by that of the repository.
+	bobKey.Owner = "alice"
+
+	if err := alice.CreateAPIKey(ctx, aliceKey); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bob.CreateAPIKey(ctx, bobKey); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := alice.GetAPIKey(ctx, bobKey.Uuid); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("alice got bob's key, with error %v", err)
+	}
+
+	keys, err := alice.ListAPIKeys(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 1 || keys[0].Uuid != aliceKey.Uuid {
+		t.Errorf("alice listed %v, want only her own key", keys)
+	}
+
+	if err := alice.DeleteAPIKey(ctx, bobKey.Uuid); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("alice deleted bob's key, with error %v", err)
+	}
+
+	// Keys are resolved without an owner when authenticating a request, which
+	// gives the owner the request is made as.
+	key, err := repository.GetAPIKey(ctx, bobKey.Uuid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.Owner != "bob" {
+		t.Errorf("bob's key is owned by '%s'", key.Owner)
+	}
+
+	if err := bob.DeleteAPIKey(ctx, bobKey.Uuid); err != nil {
+		t.Fatalf("bob could not delete the key: %v", err)
+	}
+
+	if _, err := repository.GetAPIKey(ctx, bobKey.Uuid); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("bob's key was found after it was deleted, with error %v", err)
+	}
+}
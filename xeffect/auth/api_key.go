@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+// GOAL_ACTION_RESOURCE is the resource goal actions are posted to.
+const GOAL_ACTION_RESOURCE = "/xeffect/goals/{goalId}"
+
+// requestedAction returns the type of the goal action posted in the body of
+// event, if any.
+func requestedAction(event events.APIGatewayProxyRequest) string {
+	if event.HTTPMethod != http.MethodPost || event.Resource != GOAL_ACTION_RESOURCE {
+		return ""
+	}
+
+	body := []byte(event.Body)
+	if event.IsBase64Encoded {
+		var err error
+		body, err = base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			return ""
+		}
+	}
+
+	var action struct {
+		Type string `json:"action"`
+	}
+	if err := json.Unmarshal(body, &action); err != nil {
+		return ""
+	}
+
+	return action.Type
+}
+
+// apiKeyAllows reports whether the scopes of key allow the request.
+func apiKeyAllows(key xeffect.APIKey, event events.APIGatewayProxyRequest) bool {
+	if len(key.Scopes) == 0 {
+		return true
+	}
+
+	for _, scope := range key.Scopes {
+		switch scope {
+		case xeffect.API_KEY_SCOPE_READ:
+			if event.HTTPMethod == http.MethodGet {
+				return true
+			}
+		case xeffect.API_KEY_SCOPE_MARK_COMPLETED:
+			switch requestedAction(event) {
+			case "mark_completed", "mark_completed_bulk", "undo":
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func returnForbidden(message string) (events.APIGatewayProxyResponse, error) {
+	return xeffect.JSONErrorResponse(403, "forbidden", message)
+}
+
+// Authenticator decides who a request is from before passing it on to a
+// handler.
+type Authenticator struct {
+	// Verifier verifies bearer tokens. When it is nil, requests without an API
+	// key are left to be authorized by API Gateway.
+	Verifier *Verifier
+	// APIKeys holds the API keys which are accepted as bearer tokens. When it is
+	// nil, API keys are not accepted.
+	APIKeys xeffect.APIKeyRepository
+}
+
+// authenticateAPIKey passes on a request made with an API key, as the owner of
+// the key, if the key exists and its scopes allow the request.
+func (a *Authenticator) authenticateAPIKey(ctx context.Context, event events.APIGatewayProxyRequest, token string, next Handler) (events.APIGatewayProxyResponse, error) {
+	id, secret, err := xeffect.ParseAPIKey(token)
+	if err != nil {
+		return returnUnauthorized("The API key is not valid.")
+	}
+
+	key, err := a.APIKeys.GetAPIKey(ctx, id)
+	if errors.Is(err, xeffect.ErrAPIKeyNotFound) || (err == nil && !key.Matches(secret)) {
+		log.Printf("rejected API key '%s'", id)
+		return returnUnauthorized("The API key is not valid.")
+	}
+
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	if !apiKeyAllows(key, event) {
+		return returnForbidden("The API key is not allowed to make this request.")
+	}
+
+	event.RequestContext.Authorizer = map[string]interface{}{
+		"principalId": key.Owner,
+		"api_key_id":  key.Uuid,
+	}
+
+	return next(ctx, event)
+}
+
+// Wrap returns a handler which authenticates requests before passing them on
+// to next. A bearer token which is an API key is checked against APIKeys, and
+// any other bearer token by Verifier.
+func (a *Authenticator) Wrap(next Handler) Handler {
+	verified := next
+	if a.Verifier != nil {
+		verified = a.Verifier.Middleware(next)
+	}
+
+	return func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		if token, ok := bearerToken(event.Headers); ok && xeffect.IsAPIKey(token) {
+			if a.APIKeys == nil {
+				return returnUnauthorized("API keys can not be used for this request.")
+			}
+
+			return a.authenticateAPIKey(ctx, event, token, next)
+		}
+
+		return verified(ctx, event)
+	}
+}
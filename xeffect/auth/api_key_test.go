@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+// newTestAPIKey stores a key with the given scopes for owner, returning the
+// key to be sent as a bearer token.
+func newTestAPIKey(t *testing.T, keys xeffect.APIKeyRepository, owner string, scopes ...string) string {
+	t.Helper()
+
+	key, token, err := xeffect.NewAPIKey("script", scopes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := keys.ForOwner(owner).CreateAPIKey(context.Background(), key); err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func TestAuthenticatorAPIKeys(t *testing.T) {
+	keys := xeffect.NewMemoryAPIKeyRepository()
+
+	unrestricted := newTestAPIKey(t, keys, "alice")
+	readOnly := newTestAPIKey(t, keys, "alice", xeffect.API_KEY_SCOPE_READ)
+	markOnly := newTestAPIKey(t, keys, "bob", xeffect.API_KEY_SCOPE_MARK_COMPLETED)
+
+	id, _, err := xeffect.ParseAPIKey(readOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Resource:   GOAL_ACTION_RESOURCE,
+	}
+	markCompleted := events.APIGatewayProxyRequest{
+		HTTPMethod: "POST",
+		Resource:   GOAL_ACTION_RESOURCE,
+		Body:       `{"action": "mark_completed", "date": "2021-12-01", "is_completed": true}`,
+	}
+	update := events.APIGatewayProxyRequest{
+		HTTPMethod: "PUT",
+		Resource:   GOAL_ACTION_RESOURCE,
+		Body:       `{"title": "Renamed"}`,
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		request events.APIGatewayProxyRequest
+		status  int
+		owner   string
+	}{
+		{"unrestricted get", unrestricted, get, 200, "alice"},
+		{"unrestricted mark", unrestricted, markCompleted, 200, "alice"},
+		{"unrestricted update", unrestricted, update, 200, "alice"},
+		{"read get", readOnly, get, 200, "alice"},
+		{"read mark", readOnly, markCompleted, 403, ""},
+		{"read update", readOnly, update, 403, ""},
+		{"mark get", markOnly, get, 403, ""},
+		{"mark mark", markOnly, markCompleted, 200, "bob"},
+		{"wrong secret", "xek_" + id + "_wrong", get, 401, ""},
+		{"unknown id", "xek_00000000-0000-0000-0000-000000000000_secret", get, 401, ""},
+		{"malformed", "xek_" + id, get, 401, ""},
+		{"empty", "xek_", get, 401, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			owner := ""
+			authenticator := &Authenticator{APIKeys: keys}
+			handler := authenticator.Wrap(func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+				owner, _ = xeffect.RequestOwner(event.RequestContext)
+				return events.APIGatewayProxyResponse{StatusCode: 200}, nil
+			})
+
+			request := test.request
+			request.Headers = map[string]string{"Authorization": "Bearer " + test.token}
+
+			response, err := handler(context.Background(), request)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if response.StatusCode != test.status {
+				t.Errorf("the request was answered with %d, want %d", response.StatusCode, test.status)
+			}
+
+			if owner != test.owner {
+				t.Errorf("the request was passed on as '%s', want '%s'", owner, test.owner)
+			}
+		})
+	}
+}
+
+func TestAuthenticatorWithoutAPIKeys(t *testing.T) {
+	keys := xeffect.NewMemoryAPIKeyRepository()
+	token := newTestAPIKey(t, keys, "alice")
+
+	handler := (&Authenticator{}).Wrap(func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		t.Fatal("the request was passed on")
+		return events.APIGatewayProxyResponse{}, nil
+	})
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod: "GET",
+		Headers:    map[string]string{"Authorization": "Bearer " + token},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.StatusCode != 401 {
+		t.Errorf("the request was answered with %d, want 401", response.StatusCode)
+	}
+}
@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// JWKS_REFRESH_INTERVAL is the least time between fetches of a JWKS url, so
+// that tokens with unknown key ids can not be used to flood the issuer.
+const JWKS_REFRESH_INTERVAL = time.Minute
+
+var ErrKeyNotFound = errors.New("key not found")
+
+// JSONWebKey is a single key of a JSON Web Key Set, as described by RFC 7517.
+// Only the fields of RSA and elliptic curve public keys are read.
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyId     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n"`
+	E         string `json:"e"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	Y         string `json:"y"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// VerificationKey is a public key along with the algorithm it verifies.
+type VerificationKey struct {
+	Algorithm string
+	Key       crypto.PublicKey
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(decoded), nil
+}
+
+// publicKey converts a JSON Web Key into the key and algorithm a token signed
+// with it is verified with.
+func (jwk JSONWebKey) publicKey() (VerificationKey, error) {
+	switch jwk.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(jwk.N)
+		if err != nil {
+			return VerificationKey{}, err
+		}
+
+		e, err := decodeBigInt(jwk.E)
+		if err != nil {
+			return VerificationKey{}, err
+		}
+
+		if !e.IsInt64() || e.Int64() < 3 || n.BitLen() < 2048 {
+			return VerificationKey{}, fmt.Errorf("key '%s' is not a usable RSA key", jwk.KeyId)
+		}
+
+		return VerificationKey{
+			Algorithm: RS256,
+			Key: &rsa.PublicKey{
+				N: n,
+				E: int(e.Int64()),
+			},
+		}, nil
+	case "EC":
+		if jwk.Curve != "P-256" {
+			return VerificationKey{}, fmt.Errorf("curve '%s' of key '%s' is not supported", jwk.Curve, jwk.KeyId)
+		}
+
+		x, err := decodeBigInt(jwk.X)
+		if err != nil {
+			return VerificationKey{}, err
+		}
+
+		y, err := decodeBigInt(jwk.Y)
+		if err != nil {
+			return VerificationKey{}, err
+		}
+
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return VerificationKey{}, fmt.Errorf("key '%s' is not on the P-256 curve", jwk.KeyId)
+		}
+
+		return VerificationKey{
+			Algorithm: ES256,
+			Key: &ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     x,
+				Y:     y,
+			},
+		}, nil
+	}
+
+	return VerificationKey{}, fmt.Errorf("key type '%s' of key '%s' is not supported", jwk.KeyType, jwk.KeyId)
+}
+
+// parseKeySet reads the signing keys of a JSON Web Key Set. Keys which can not
+// be used to verify RS256 or ES256 signatures are skipped.
+func parseKeySet(data []byte) (map[string]VerificationKey, error) {
+	var keySet JSONWebKeySet
+	if err := json.Unmarshal(data, &keySet); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]VerificationKey{}
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+
+		if jwk.Algorithm != "" && jwk.Algorithm != key.Algorithm {
+			continue
+		}
+
+		keys[jwk.KeyId] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("the key set has no usable signing keys")
+	}
+
+	return keys, nil
+}
+
+// KeySet finds the key a token was signed with.
+type KeySet interface {
+	// Key returns the key with the given id, or ErrKeyNotFound when the set has
+	// no such key. An empty id is only found when the set holds a single key.
+	Key(ctx context.Context, id string) (VerificationKey, error)
+}
+
+// StaticKeySet is a key set which never changes, such as one read from a file.
+type StaticKeySet struct {
+	keys map[string]VerificationKey
+}
+
+func NewStaticKeySet(data []byte) (*StaticKeySet, error) {
+	keys, err := parseKeySet(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StaticKeySet{
+		keys: keys,
+	}, nil
+}
+
+func NewFileKeySet(path string) (*StaticKeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewStaticKeySet(data)
+}
+
+func findKey(keys map[string]VerificationKey, id string) (VerificationKey, bool) {
+	if key, ok := keys[id]; ok {
+		return key, true
+	}
+
+	if id == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+
+	return VerificationKey{}, false
+}
+
+func (s *StaticKeySet) Key(ctx context.Context, id string) (VerificationKey, error) {
+	key, ok := findKey(s.keys, id)
+	if !ok {
+		return VerificationKey{}, ErrKeyNotFound
+	}
+
+	return key, nil
+}
+
+// RemoteKeySet is a key set fetched from a url. The keys are fetched when first
+// needed, and again when a token is signed with a key which is not in the set,
+// so that keys can be rotated by the issuer.
+type RemoteKeySet struct {
+	url    string
+	client *http.Client
+
+	mu        sync.Mutex
+	keys      map[string]VerificationKey
+	fetchedAt time.Time
+	// fetchErr is the error of the last fetch, if it failed, which is returned
+	// until the url is fetched again.
+	fetchErr error
+}
+
+func NewRemoteKeySet(url string) *RemoteKeySet {
+	return &RemoteKeySet{
+		url: url,
+		client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+}
+
+func (s *RemoteKeySet) fetch(ctx context.Context) (map[string]VerificationKey, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching '%s' returned status %d", s.url, response.StatusCode)
+	}
+
+	var data json.RawMessage
+	if err := json.NewDecoder(response.Body).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	return parseKeySet(data)
+}
+
+func (s *RemoteKeySet) Key(ctx context.Context, id string) (VerificationKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key, ok := findKey(s.keys, id); ok {
+		return key, nil
+	}
+
+	if !s.fetchedAt.IsZero() && time.Since(s.fetchedAt) < JWKS_REFRESH_INTERVAL {
+		if s.fetchErr != nil {
+			return VerificationKey{}, s.fetchErr
+		}
+
+		return VerificationKey{}, ErrKeyNotFound
+	}
+
+	keys, err := s.fetch(ctx)
+	s.fetchedAt = time.Now()
+	s.fetchErr = nil
+	if err != nil {
+		s.fetchErr = fmt.Errorf("fetching the key set: %w", err)
+		return VerificationKey{}, s.fetchErr
+	}
+	s.keys = keys
+
+	key, ok := findKey(s.keys, id)
+	if !ok {
+		return VerificationKey{}, ErrKeyNotFound
+	}
+
+	return key, nil
+}
@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	RS256 = "RS256"
+	ES256 = "ES256"
+)
+
+// CLOCK_SKEW is how far the clock of the token issuer may differ from ours.
+const CLOCK_SKEW = time.Minute
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Claims are the claims of a verified token. Subject is always set.
+type Claims struct {
+	Subject string
+	// All holds every claim of the token, as decoded from JSON.
+	All map[string]interface{}
+}
+
+// Verifier verifies JSON Web Tokens signed with RS256 or ES256 by a key in its
+// key set. When Issuer or Audience are set, tokens must have been issued by
+// Issuer for Audience.
+type Verifier struct {
+	Keys     KeySet
+	Issuer   string
+	Audience string
+	// Now returns the current time, and is only replaced to verify tokens as of
+	// another time.
+	Now func() time.Time
+}
+
+func NewVerifier(keys KeySet, issuer string, audience string) *Verifier {
+	return &Verifier{
+		Keys:     keys,
+		Issuer:   issuer,
+		Audience: audience,
+		Now:      time.Now,
+	}
+}
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	KeyId     string `json:"kid"`
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(decoded, v)
+}
+
+func verifySignature(key VerificationKey, signingInput string, signature []byte) error {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch publicKey := key.Key.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, digest[:], signature)
+	case *ecdsa.PublicKey:
+		// An ES256 signature is the 32 byte r and s values one after the other,
+		// rather than the ASN.1 encoding used elsewhere.
+		if len(signature) != 64 {
+			return errors.New("the signature is not 64 bytes long")
+		}
+
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(publicKey, digest[:], r, s) {
+			return errors.New("the signature does not match")
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("key of type %T is not supported", key.Key)
+}
+
+// numericDate reads a NumericDate claim, the number of seconds since the epoch.
+func numericDate(claims map[string]interface{}, name string) (time.Time, bool, error) {
+	value, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("the '%s' claim is not a number", name)
+	}
+
+	return time.Unix(int64(seconds), 0), true, nil
+}
+
+// hasAudience reports whether the audience claim, which is either a single
+// string or a list of them, contains audience.
+func hasAudience(claims map[string]interface{}, audience string) bool {
+	switch value := claims["aud"].(type) {
+	case string:
+		return value == audience
+	case []interface{}:
+		for _, item := range value {
+			if item == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Verify checks the signature and claims of a compact serialised token,
+// returning its claims. Every error with the token wraps ErrInvalidToken, but
+// an error finding the keys to check it with does not, as the token may still
+// be valid.
+func (v *Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+	invalid := func(err error) (Claims, error) {
+		return Claims{}, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	segments := strings.Split(token, ".")
+	if len(segments) != 3 {
+		return invalid(errors.New("the token is not made of three segments"))
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(segments[0], &header); err != nil {
+		return invalid(err)
+	}
+
+	// The algorithm is checked against the key, rather than trusted from the
+	// header, so a token can not choose a weaker algorithm than its key.
+	if header.Algorithm != RS256 && header.Algorithm != ES256 {
+		return invalid(fmt.Errorf("algorithm '%s' is not supported", header.Algorithm))
+	}
+
+	key, err := v.Keys.Key(ctx, header.KeyId)
+	if errors.Is(err, ErrKeyNotFound) {
+		return invalid(fmt.Errorf("key '%s': %v", header.KeyId, err))
+	}
+
+	if err != nil {
+		return Claims{}, fmt.Errorf("finding key '%s': %w", header.KeyId, err)
+	}
+
+	if key.Algorithm != header.Algorithm {
+		return invalid(fmt.Errorf("key '%s' does not sign with %s", header.KeyId, header.Algorithm))
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+	if err != nil {
+		return invalid(err)
+	}
+
+	if err := verifySignature(key, segments[0]+"."+segments[1], signature); err != nil {
+		return invalid(err)
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(segments[1], &claims); err != nil {
+		return invalid(err)
+	}
+
+	now := v.Now()
+
+	expiry, ok, err := numericDate(claims, "exp")
+	if err != nil {
+		return invalid(err)
+	}
+	if !ok {
+		return invalid(errors.New("the token has no expiry"))
+	}
+	if now.After(expiry.Add(CLOCK_SKEW)) {
+		return invalid(errors.New("the token has expired"))
+	}
+
+	notBefore, ok, err := numericDate(claims, "nbf")
+	if err != nil {
+		return invalid(err)
+	}
+	if ok && now.Add(CLOCK_SKEW).Before(notBefore) {
+		return invalid(errors.New("the token is not valid yet"))
+	}
+
+	if v.Issuer != "" && claims["iss"] != v.Issuer {
+		return invalid(fmt.Errorf("the token was not issued by '%s'", v.Issuer))
+	}
+
+	if v.Audience != "" && !hasAudience(claims, v.Audience) {
+		return invalid(fmt.Errorf("the token is not for audience '%s'", v.Audience))
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return invalid(errors.New("the token has no subject"))
+	}
+
+	return Claims{
+		Subject: subject,
+		All:     claims,
+	}, nil
+}
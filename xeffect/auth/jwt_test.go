@@ -0,0 +1,441 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+const (
+	TEST_ISSUER   = "https://issuer.example.com/"
+	TEST_AUDIENCE = "xeffect"
+)
+
+// testNow is the time tokens are verified as of in these tests.
+var testNow = time.Date(2021, time.December, 1, 12, 0, 0, 0, time.UTC)
+
+// testKey is a locally generated key which tokens are signed with.
+type testKey struct {
+	id        string
+	algorithm string
+	private   crypto.Signer
+}
+
+func newRSAKey(t *testing.T, id string) testKey {
+	t.Helper()
+
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testKey{id: id, algorithm: RS256, private: private}
+}
+
+func newECKey(t *testing.T, id string) testKey {
+	t.Helper()
+
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testKey{id: id, algorithm: ES256, private: private}
+}
+
+func encodeBigInt(value *big.Int, size int) string {
+	return base64.RawURLEncoding.EncodeToString(value.FillBytes(make([]byte, size)))
+}
+
+func (k testKey) jwk() JSONWebKey {
+	switch private := k.private.(type) {
+	case *rsa.PrivateKey:
+		return JSONWebKey{
+			KeyType:   "RSA",
+			KeyId:     k.id,
+			Use:       "sig",
+			Algorithm: RS256,
+			N:         base64.RawURLEncoding.EncodeToString(private.N.Bytes()),
+			E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(private.E)).Bytes()),
+		}
+	case *ecdsa.PrivateKey:
+		return JSONWebKey{
+			KeyType:   "EC",
+			KeyId:     k.id,
+			Use:       "sig",
+			Algorithm: ES256,
+			Curve:     "P-256",
+			X:         encodeBigInt(private.X, 32),
+			Y:         encodeBigInt(private.Y, 32),
+		}
+	}
+
+	return JSONWebKey{}
+}
+
+func keySetJSON(t *testing.T, keys ...testKey) []byte {
+	t.Helper()
+
+	var keySet JSONWebKeySet
+	for _, key := range keys {
+		keySet.Keys = append(keySet.Keys, key.jwk())
+	}
+
+	data, err := json.Marshal(keySet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+// sign mints a token with the given header and claims, signed by key.
+func (k testKey) sign(t *testing.T, header map[string]interface{}, claims map[string]interface{}) string {
+	t.Helper()
+
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signingInput := encode(header) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	var signature []byte
+	switch private := k.private.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, private, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, private, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (k testKey) header() map[string]interface{} {
+	return map[string]interface{}{
+		"alg": k.algorithm,
+		"kid": k.id,
+		"typ": "JWT",
+	}
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub": "user-1",
+		"iss": TEST_ISSUER,
+		"aud": TEST_AUDIENCE,
+		"exp": testNow.Add(time.Hour).Unix(),
+		"iat": testNow.Unix(),
+	}
+}
+
+func withClaim(name string, value interface{}) map[string]interface{} {
+	claims := validClaims()
+	if value == nil {
+		delete(claims, name)
+	} else {
+		claims[name] = value
+	}
+
+	return claims
+}
+
+func newTestVerifier(t *testing.T, keys ...testKey) *Verifier {
+	t.Helper()
+
+	keySet, err := NewStaticKeySet(keySetJSON(t, keys...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verifier := NewVerifier(keySet, TEST_ISSUER, TEST_AUDIENCE)
+	verifier.Now = func() time.Time { return testNow }
+
+	return verifier
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa-1")
+	ecKey := newECKey(t, "ec-1")
+	otherRSAKey := newRSAKey(t, "rsa-1")
+	otherECKey := newECKey(t, "ec-1")
+
+	verifier := newTestVerifier(t, rsaKey, ecKey)
+
+	tamper := func(token string) string {
+		dot := strings.LastIndex(token, ".")
+		signature, err := base64.RawURLEncoding.DecodeString(token[dot+1:])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		signature[0] ^= 0xff
+
+		return token[:dot+1] + base64.RawURLEncoding.EncodeToString(signature)
+	}
+
+	withHeader := func(key testKey, name string, value interface{}) map[string]interface{} {
+		header := key.header()
+		header[name] = value
+
+		return header
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"RS256", rsaKey.sign(t, rsaKey.header(), validClaims()), true},
+		{"ES256", ecKey.sign(t, ecKey.header(), validClaims()), true},
+		{"audience list", rsaKey.sign(t, rsaKey.header(), withClaim("aud", []string{"other", TEST_AUDIENCE})), true},
+		{"RS256 signed by another key", otherRSAKey.sign(t, rsaKey.header(), validClaims()), false},
+		{"ES256 signed by another key", otherECKey.sign(t, ecKey.header(), validClaims()), false},
+		{"RS256 tampered signature", tamper(rsaKey.sign(t, rsaKey.header(), validClaims())), false},
+		{"ES256 tampered signature", tamper(ecKey.sign(t, ecKey.header(), validClaims())), false},
+		{"expired within skew", rsaKey.sign(t, rsaKey.header(), withClaim("exp", testNow.Add(-30*time.Second).Unix())), true},
+		{"expired beyond skew", rsaKey.sign(t, rsaKey.header(), withClaim("exp", testNow.Add(-2*time.Minute).Unix())), false},
+		{"no expiry", rsaKey.sign(t, rsaKey.header(), withClaim("exp", nil)), false},
+		{"not before within skew", rsaKey.sign(t, rsaKey.header(), withClaim("nbf", testNow.Add(30*time.Second).Unix())), true},
+		{"not before beyond skew", rsaKey.sign(t, rsaKey.header(), withClaim("nbf", testNow.Add(2*time.Minute).Unix())), false},
+		{"wrong issuer", rsaKey.sign(t, rsaKey.header(), withClaim("iss", "https://other.example.com/")), false},
+		{"no issuer", rsaKey.sign(t, rsaKey.header(), withClaim("iss", nil)), false},
+		{"wrong audience", rsaKey.sign(t, rsaKey.header(), withClaim("aud", "other")), false},
+		{"wrong audience list", rsaKey.sign(t, rsaKey.header(), withClaim("aud", []string{"other"})), false},
+		{"no subject", rsaKey.sign(t, rsaKey.header(), withClaim("sub", nil)), false},
+		{"empty subject", rsaKey.sign(t, rsaKey.header(), withClaim("sub", "")), false},
+		{"ES256 header on an RSA key", rsaKey.sign(t, withHeader(rsaKey, "alg", ES256), validClaims()), false},
+		{"RS256 header on an EC key", ecKey.sign(t, withHeader(ecKey, "alg", RS256), validClaims()), false},
+		{"none algorithm", rsaKey.sign(t, withHeader(rsaKey, "alg", "none"), validClaims()), false},
+		{"HS256 algorithm", rsaKey.sign(t, withHeader(rsaKey, "alg", "HS256"), validClaims()), false},
+		{"unknown key id", rsaKey.sign(t, withHeader(rsaKey, "kid", "rsa-2"), validClaims()), false},
+		{"no key id with several keys", rsaKey.sign(t, withHeader(rsaKey, "kid", ""), validClaims()), false},
+		{"two segments", "a.b", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims, err := verifier.Verify(context.Background(), test.token)
+			if test.valid {
+				if err != nil {
+					t.Fatalf("Verify returned %v, want no error", err)
+				}
+
+				if claims.Subject != "user-1" {
+					t.Errorf("Subject is '%s', want 'user-1'", claims.Subject)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, ErrInvalidToken) {
+				t.Fatalf("Verify returned %v, want an error wrapping ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyWithoutIssuerOrAudience(t *testing.T) {
+	key := newECKey(t, "")
+
+	verifier := newTestVerifier(t, key)
+	verifier.Issuer = ""
+	verifier.Audience = ""
+
+	claims := validClaims()
+	delete(claims, "iss")
+	delete(claims, "aud")
+
+	// A token without a key id is verified by the only key of the set.
+	header := key.header()
+	delete(header, "kid")
+
+	if _, err := verifier.Verify(context.Background(), key.sign(t, header, claims)); err != nil {
+		t.Fatalf("Verify returned %v, want no error", err)
+	}
+}
+
+func TestStaticKeySetUnknownKey(t *testing.T) {
+	keySet, err := NewStaticKeySet(keySetJSON(t, newRSAKey(t, "rsa-1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := keySet.Key(context.Background(), "rsa-1"); err != nil {
+		t.Fatalf("Key returned %v for a known key", err)
+	}
+
+	if _, err := keySet.Key(context.Background(), "rsa-2"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Key returned %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestRemoteKeySet(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa-1")
+	rotatedKey := newECKey(t, "ec-1")
+
+	var fetches int32
+	var keySet atomic.Value
+	keySet.Store(keySetJSON(t, rsaKey))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(keySet.Load().([]byte))
+	}))
+	defer server.Close()
+
+	keys := NewRemoteKeySet(server.URL)
+	verifier := NewVerifier(keys, TEST_ISSUER, TEST_AUDIENCE)
+	verifier.Now = func() time.Time { return testNow }
+
+	if _, err := verifier.Verify(context.Background(), rsaKey.sign(t, rsaKey.header(), validClaims())); err != nil {
+		t.Fatalf("Verify returned %v, want no error", err)
+	}
+
+	// A key which is not in the set is only looked for again once the set has
+	// not been fetched for a while.
+	keySet.Store(keySetJSON(t, rsaKey, rotatedKey))
+	rotated := rotatedKey.sign(t, rotatedKey.header(), validClaims())
+
+	if _, err := verifier.Verify(context.Background(), rotated); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify returned %v, want an error wrapping ErrInvalidToken", err)
+	}
+
+	if got := atomic.LoadInt32(&fetches); got != 1 {
+		t.Fatalf("the key set was fetched %d times, want 1", got)
+	}
+
+	keys.fetchedAt = keys.fetchedAt.Add(-JWKS_REFRESH_INTERVAL)
+
+	if _, err := verifier.Verify(context.Background(), rotated); err != nil {
+		t.Fatalf("Verify returned %v after the key was rotated in, want no error", err)
+	}
+
+	if got := atomic.LoadInt32(&fetches); got != 2 {
+		t.Fatalf("the key set was fetched %d times, want 2", got)
+	}
+
+	unknown := newRSAKey(t, "rsa-2")
+	keys.fetchedAt = keys.fetchedAt.Add(-JWKS_REFRESH_INTERVAL)
+
+	if _, err := verifier.Verify(context.Background(), unknown.sign(t, unknown.header(), validClaims())); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify returned %v for an unknown key id, want an error wrapping ErrInvalidToken", err)
+	}
+}
+
+func TestRemoteKeySetUnavailable(t *testing.T) {
+	key := newRSAKey(t, "rsa-1")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	verifier := NewVerifier(NewRemoteKeySet(server.URL), TEST_ISSUER, TEST_AUDIENCE)
+	verifier.Now = func() time.Time { return testNow }
+
+	token := key.sign(t, key.header(), validClaims())
+
+	// The failure is returned again until the key set is next fetched, rather
+	// than the key being reported as not found.
+	for i := 0; i < 2; i++ {
+		_, err := verifier.Verify(context.Background(), token)
+		if err == nil || errors.Is(err, ErrInvalidToken) {
+			t.Fatalf("Verify returned %v, want an error not wrapping ErrInvalidToken", err)
+		}
+	}
+
+	handler := verifier.Middleware(func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		t.Fatal("the request was passed on without a verified token")
+		return events.APIGatewayProxyResponse{}, nil
+	})
+
+	response, err := handler(context.Background(), events.APIGatewayProxyRequest{
+		Headers: map[string]string{"Authorization": "Bearer " + token},
+	})
+	if err == nil {
+		t.Fatalf("the middleware answered %d, want an error", response.StatusCode)
+	}
+}
+
+func TestNewVerifierFromEnv(t *testing.T) {
+	key := newRSAKey(t, "rsa-1")
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, keySetJSON(t, key), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("XEFFECT_JWKS_URL", "")
+	t.Setenv("XEFFECT_JWKS_FILE", path)
+
+	// A key set without an issuer and audience would accept tokens issued for
+	// any other service using the same keys.
+	for _, env := range [][2]string{{"", TEST_AUDIENCE}, {TEST_ISSUER, ""}} {
+		t.Setenv("XEFFECT_JWT_ISSUER", env[0])
+		t.Setenv("XEFFECT_JWT_AUDIENCE", env[1])
+
+		if verifier, err := NewVerifierFromEnv(); err == nil {
+			t.Fatalf("NewVerifierFromEnv returned %+v with issuer '%s' and audience '%s', want an error", verifier, env[0], env[1])
+		}
+	}
+
+	t.Setenv("XEFFECT_JWT_ISSUER", TEST_ISSUER)
+	t.Setenv("XEFFECT_JWT_AUDIENCE", TEST_AUDIENCE)
+
+	verifier, err := NewVerifierFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier.Now = func() time.Time { return testNow }
+
+	if _, err := verifier.Verify(context.Background(), key.sign(t, key.header(), validClaims())); err != nil {
+		t.Fatalf("Verify returned %v, want no error", err)
+	}
+
+	token := key.sign(t, key.header(), withClaim("aud", "another-service"))
+	if _, err := verifier.Verify(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify returned %v for another audience, want an error wrapping ErrInvalidToken", err)
+	}
+
+	// Without a key set, requests are left to API Gateway.
+	t.Setenv("XEFFECT_JWKS_FILE", "")
+	t.Setenv("XEFFECT_JWT_ISSUER", "")
+
+	if verifier, err := NewVerifierFromEnv(); verifier != nil || err != nil {
+		t.Fatalf("NewVerifierFromEnv returned %+v and %v without a key set, want neither", verifier, err)
+	}
+}
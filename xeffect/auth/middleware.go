@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+// Handler is a lambda handler for API Gateway proxy events.
+type Handler func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+func returnUnauthorized(message string) (events.APIGatewayProxyResponse, error) {
+	response, err := xeffect.JSONErrorResponse(401, "unauthorized", message)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	response.Headers["WWW-Authenticate"] = "Bearer"
+
+	return response, nil
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(headers map[string]string) (string, bool) {
+	for name, value := range headers {
+		if !strings.EqualFold(name, "authorization") {
+			continue
+		}
+
+		scheme, token, ok := cut(strings.TrimSpace(value), " ")
+		if !ok || !strings.EqualFold(scheme, "bearer") {
+			return "", false
+		}
+
+		token = strings.TrimSpace(token)
+		return token, token != ""
+	}
+
+	return "", false
+}
+
+// cut splits s around the first instance of sep.
+func cut(s string, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
+
+// Middleware only passes on requests with a valid bearer token, answering any
+// other request with a 401. The claims of the token replace the authorizer
+// context of the request, in the shape API Gateway gives the claims of a
+// verified token, so handlers find the subject with xeffect.RequestOwner.
+func (v *Verifier) Middleware(next Handler) Handler {
+	return func(ctx context.Context, event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+		token, ok := bearerToken(event.Headers)
+		if !ok {
+			return returnUnauthorized("The request has no bearer token.")
+		}
+
+		claims, err := v.Verify(ctx, token)
+		if err != nil {
+			if !errors.Is(err, ErrInvalidToken) {
+				return events.APIGatewayProxyResponse{}, err
+			}
+
+			log.Printf("rejected bearer token: %v", err)
+			return returnUnauthorized("The bearer token is not valid.")
+		}
+
+		event.RequestContext.Authorizer = map[string]interface{}{
+			"principalId": claims.Subject,
+			"claims":      claims.All,
+		}
+
+		return next(ctx, event)
+	}
+}
+
+// NewVerifierFromEnv creates a verifier from the environment of the lambda.
+// XEFFECT_JWKS_URL or XEFFECT_JWKS_FILE give the keys tokens are signed with,
+// and XEFFECT_JWT_ISSUER and XEFFECT_JWT_AUDIENCE the issuer and audience they
+// must have, which are both required along with a key set so that tokens
+// issued for another service are not accepted. When neither key set is given
+// it returns nil, and requests are left to be authorized by API Gateway.
+func NewVerifierFromEnv() (*Verifier, error) {
+	var keys KeySet
+	if url := os.Getenv("XEFFECT_JWKS_URL"); url != "" {
+		keys = NewRemoteKeySet(url)
+	} else if path := os.Getenv("XEFFECT_JWKS_FILE"); path != "" {
+		var err error
+		keys, err = NewFileKeySet(path)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		return nil, nil
+	}
+
+	issuer := os.Getenv("XEFFECT_JWT_ISSUER")
+	audience := os.Getenv("XEFFECT_JWT_AUDIENCE")
+	if issuer == "" || audience == "" {
+		return nil, errors.New("XEFFECT_JWT_ISSUER and XEFFECT_JWT_AUDIENCE must be set to verify bearer tokens")
+	}
+
+	return NewVerifier(keys, issuer, audience), nil
+}
@@ -0,0 +1,154 @@
+package xeffect
+
+import (
+	"sort"
+	"time"
+)
+
+// CARD_DAYS is the number of days on a card, laid out as a 7x7 grid.
+const CARD_DAYS = 49
+
+// The statuses of a card.
+const (
+	CARD_IN_PROGRESS = "in-progress"
+	CARD_COMPLETED   = "completed"
+	CARD_FAILED      = "failed"
+)
+
+// The statuses of a day on a card.
+const (
+	CARD_DAY_COMPLETED = "completed"
+	CARD_DAY_MISSED    = "missed"
+	CARD_DAY_PENDING   = "pending"
+	CARD_DAY_REST      = "rest"
+)
+
+// Card is a run of up to CARD_DAYS days of a goal. A card is completed once
+// every one of its days is completed, or is a rest day the schedule of the goal
+// allows to be skipped, and fails on the first of its days to be missed, in
+// which case it ends on that day.
+type Card struct {
+	Number        int       `json:"number"`
+	StartDate     string    `json:"start_date"`
+	EndDate       string    `json:"end_date"`
+	Status        string    `json:"status"`
+	CompletedDays int       `json:"completed_days"`
+	Days          []CardDay `json:"days,omitempty"`
+}
+
+// CardDay is a single day of a card. Days not yet completed are pending until
+// they have passed, when they are missed, unless they are rest days.
+type CardDay struct {
+	Date   string `json:"date"`
+	Status string `json:"status"`
+}
+
+// GoalCards works out every card of a goal as of date, oldest first, along with
+// its days. The first card starts when the goal was created, or on the first
+// day it was completed if that is earlier. Each following card starts the day
+// after a completed card, or on the first day completed after a failed card,
+// and otherwise on date. The last card is the current card.
+func GoalCards(goal Goal, date string) ([]Card, error) {
+	asOfDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := CompletedDays(goal)
+	if err != nil {
+		return nil, err
+	}
+
+	completedDates := make([]string, 0, len(days))
+	for day := range days {
+		completedDates = append(completedDates, day)
+	}
+	sort.Strings(completedDates)
+
+	start := asOfDate
+	if goal.CreatedAt != "" {
+		start, err = time.Parse("2006-01-02", goal.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(completedDates) > 0 {
+		firstDate, err := time.Parse("2006-01-02", completedDates[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if firstDate.Before(start) {
+			start = firstDate
+		}
+	}
+
+	// nextCompleted returns the first completed day after a day, up to and
+	// including date, or date when there is none.
+	nextCompleted := func(after time.Time) time.Time {
+		i := sort.SearchStrings(completedDates, after.AddDate(0, 0, 1).Format("2006-01-02"))
+		if i < len(completedDates) {
+			next, err := time.Parse("2006-01-02", completedDates[i])
+			if err == nil && !next.After(asOfDate) {
+				return next
+			}
+		}
+
+		return asOfDate
+	}
+
+	cards := []Card{}
+	for len(cards) == 0 || !start.After(asOfDate) {
+		card := Card{
+			Number:    len(cards) + 1,
+			StartDate: start.Format("2006-01-02"),
+			EndDate:   start.AddDate(0, 0, CARD_DAYS-1).Format("2006-01-02"),
+			Status:    CARD_IN_PROGRESS,
+			Days:      make([]CardDay, 0, CARD_DAYS),
+		}
+		restDays := 0
+
+		for i := 0; i < CARD_DAYS && card.Status == CARD_IN_PROGRESS; i++ {
+			day := start.AddDate(0, 0, i)
+			cardDay := CardDay{
+				Date:   day.Format("2006-01-02"),
+				Status: CARD_DAY_PENDING,
+			}
+
+			// The date itself is never missed, as it may still be completed.
+			switch {
+			case days[cardDay.Date]:
+				cardDay.Status = CARD_DAY_COMPLETED
+				card.CompletedDays++
+			case goal.Schedule.Skippable(day, days):
+				cardDay.Status = CARD_DAY_REST
+				restDays++
+			case goal.Schedule.missed(day, asOfDate, days):
+				cardDay.Status = CARD_DAY_MISSED
+				card.Status = CARD_FAILED
+				card.EndDate = cardDay.Date
+			}
+
+			card.Days = append(card.Days, cardDay)
+		}
+
+		switch {
+		case card.Status == CARD_FAILED:
+			end, err := time.Parse("2006-01-02", card.EndDate)
+			if err != nil {
+				return nil, err
+			}
+			start = nextCompleted(end)
+		case card.CompletedDays+restDays == CARD_DAYS:
+			card.Status = CARD_COMPLETED
+			start = start.AddDate(0, 0, CARD_DAYS)
+		default:
+			// The card is still in progress, so no card follows it yet.
+			start = asOfDate.AddDate(0, 0, 1)
+		}
+
+		cards = append(cards, card)
+	}
+
+	return cards, nil
+}
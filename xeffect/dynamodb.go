@@ -0,0 +1,869 @@
+package xeffect
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const GOAL_TABLE = "xeffect_goals"
+
+// GOAL_OWNER_INDEX is the index of the goals table keyed by the owner of each
+// goal.
+const GOAL_OWNER_INDEX = "owner_index"
+
+// COMPLETION_EVENT_TABLE holds the completion events of every goal, keyed by
+// the uuid of the goal and the sequence number of the event.
+const COMPLETION_EVENT_TABLE = "xeffect_completion_events"
+
+// MAX_BATCH_WRITE_ITEMS is the most items a single BatchWriteItem can write.
+const MAX_BATCH_WRITE_ITEMS = 25
+
+// MAX_BATCH_WRITE_ATTEMPTS is the number of times a batch of writes is tried
+// while DynamoDB leaves some of them unprocessed.
+const MAX_BATCH_WRITE_ATTEMPTS = 5
+
+// DynamoDBGoalRepository stores goals in the goals table, with one item per
+// goal keyed by its uuid, and their completion events in the events table.
+type DynamoDBGoalRepository struct {
+	client     *dynamodb.Client
+	table      string
+	eventTable string
+	owner      string
+}
+
+func NewDynamoDBGoalRepository(client *dynamodb.Client) *DynamoDBGoalRepository {
+	return &DynamoDBGoalRepository{
+		client:     client,
+		table:      GOAL_TABLE,
+		eventTable: COMPLETION_EVENT_TABLE,
+	}
+}
+
+func (r *DynamoDBGoalRepository) ForOwner(owner string) GoalRepository {
+	return &DynamoDBGoalRepository{
+		client:     r.client,
+		table:      r.table,
+		eventTable: r.eventTable,
+		owner:      owner,
+	}
+}
+
+func (r *DynamoDBGoalRepository) key(id string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"uuid": &types.AttributeValueMemberS{
+			Value: id,
+		},
+	}
+}
+
+func (r *DynamoDBGoalRepository) CreateGoal(ctx context.Context, goal Goal) error {
+	// The streaks must be stored as an empty map and list rather than as null, so
+	// that streaks can later be added to them.
+	if goal.Streaks == nil {
+		goal.Streaks = map[string]GoalStreak{}
+	}
+	if goal.StreakDates == nil {
+		goal.StreakDates = []string{}
+	}
+	if r.owner != "" {
+		goal.Owner = r.owner
+	}
+
+	item, err := attributevalue.MarshalMap(goal)
+	if err != nil {
+		return err
+	}
+
+	input := &dynamodb.PutItemInput{
+		Item:      item,
+		TableName: aws.String(r.table),
+	}
+
+	_, err = r.client.PutItem(ctx, input)
+
+	return err
+}
+
+func (r *DynamoDBGoalRepository) GetGoal(ctx context.Context, id string) (Goal, error) {
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(r.table),
+		Key:       r.key(id),
+	}
+
+	result, err := r.client.GetItem(ctx, input)
+	if err != nil {
+		return Goal{}, err
+	}
+
+	if len(result.Item) == 0 {
+		return Goal{}, ErrGoalNotFound
+	}
+
+	var goal Goal
+	if err := attributevalue.UnmarshalMap(result.Item, &goal); err != nil {
+		return Goal{}, err
+	}
+
+	if r.owner != "" && goal.Owner != r.owner {
+		return Goal{}, ErrGoalNotFound
+	}
+
+	return goal, nil
+}
+
+// encodePageToken converts the key a scan stopped at into an opaque token.
+func encodePageToken(key map[string]types.AttributeValue) (string, error) {
+	var values map[string]string
+	if err := attributevalue.UnmarshalMap(key, &values); err != nil {
+		return "", err
+	}
+
+	token, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// decodePageToken converts a token created by encodePageToken back into the key
+// the next scan should start from. A token from the owner index is only valid
+// for the owner it was created for.
+func decodePageToken(token string, owner string) (map[string]types.AttributeValue, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(decoded, &values); err != nil {
+		return nil, ErrInvalidPageToken
+	}
+
+	if values["Owner"] != owner {
+		return nil, ErrInvalidPageToken
+	}
+
+	return attributevalue.MarshalMap(values)
+}
+
+// ListGoals scans the goals table, unless the repository belongs to an owner,
+// in which case only the owner's goals are read from the owner index.
+func (r *DynamoDBGoalRepository) ListGoals(ctx context.Context, query GoalQuery) (GoalPage, error) {
+	var startKey map[string]types.AttributeValue
+	if query.PageToken != "" {
+		var err error
+		startKey, err = decodePageToken(query.PageToken, r.owner)
+		if err != nil {
+			return GoalPage{}, err
+		}
+	}
+
+	var filterExpression *string
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+
+	// Archived goals are hidden unless they have been asked for.
+	if !query.IncludeArchived {
+		filterExpression = aws.String("attribute_not_exists(#archived) OR #archived = :false")
+		names["#archived"] = "Archived"
+		values[":false"] = &types.AttributeValueMemberBOOL{
+			Value: false,
+		}
+	}
+
+	// list reads the goals from a single scan or query of the table, returning
+	// the key the next one should start from.
+	list := func(limit int32) ([]map[string]types.AttributeValue, map[string]types.AttributeValue, error) {
+		if r.owner == "" {
+			input := &dynamodb.ScanInput{
+				TableName:         aws.String(r.table),
+				ExclusiveStartKey: startKey,
+				Limit:             aws.Int32(limit),
+				FilterExpression:  filterExpression,
+			}
+			if len(names) > 0 {
+				input.ExpressionAttributeNames = names
+				input.ExpressionAttributeValues = values
+			}
+
+			result, err := r.client.Scan(ctx, input)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			return result.Items, result.LastEvaluatedKey, nil
+		}
+
+		names["#owner"] = "Owner"
+		values[":owner"] = &types.AttributeValueMemberS{
+			Value: r.owner,
+		}
+
+		input := &dynamodb.QueryInput{
+			TableName:                 aws.String(r.table),
+			IndexName:                 aws.String(GOAL_OWNER_INDEX),
+			KeyConditionExpression:    aws.String("#owner = :owner"),
+			ExclusiveStartKey:         startKey,
+			Limit:                     aws.Int32(limit),
+			FilterExpression:          filterExpression,
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+		}
+
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return result.Items, result.LastEvaluatedKey, nil
+	}
+
+	// The limit applies before archived goals are filtered out, so the table is
+	// read until the page is full or there are no goals left. Limiting each read
+	// to the space left in the page means no goal is ever skipped.
+	goals := []Goal{}
+	for {
+		items, lastKey, err := list(int32(query.PageSize - len(goals)))
+		if err != nil {
+			return GoalPage{}, err
+		}
+
+		page := []Goal{}
+		if err := attributevalue.UnmarshalListOfMaps(items, &page); err != nil {
+			return GoalPage{}, err
+		}
+		goals = append(goals, page...)
+
+		startKey = lastKey
+		if len(startKey) == 0 || len(goals) >= query.PageSize {
+			break
+		}
+	}
+
+	page := GoalPage{
+		Goals: goals,
+	}
+
+	if len(startKey) > 0 {
+		var err error
+		page.NextPageToken, err = encodePageToken(startKey)
+		if err != nil {
+			return GoalPage{}, err
+		}
+	}
+
+	return page, nil
+}
+
+// condition returns the condition a write to an existing goal must meet, adding
+// the names and values it uses. A goal must exist, and belong to the owner of
+// the repository, to be written.
+func (r *DynamoDBGoalRepository) condition(names map[string]string, values map[string]types.AttributeValue) *string {
+	names["#uuid"] = "uuid"
+	if r.owner == "" {
+		return aws.String("attribute_exists(#uuid)")
+	}
+
+	names["#owner"] = "Owner"
+	values[":owner"] = &types.AttributeValueMemberS{
+		Value: r.owner,
+	}
+
+	return aws.String("attribute_exists(#uuid) AND #owner = :owner")
+}
+
+// conditionFailed reports whether err is the failure of the condition of a
+// write, or of any write in a transaction.
+func conditionFailed(err error) bool {
+	var conditionalCheckFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionalCheckFailed) {
+		return true
+	}
+
+	var transactionCanceled *types.TransactionCanceledException
+	if errors.As(err, &transactionCanceled) {
+		for _, reason := range transactionCanceled.CancellationReasons {
+			if aws.ToString(reason.Code) == "ConditionalCheckFailed" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// transactUpdate applies input in a transaction which also puts events, so that
+// the update is never made without its events being recorded. An event is never
+// overwritten, so the transaction fails if another update has already written
+// an event with the same sequence number.
+func (r *DynamoDBGoalRepository) transactUpdate(ctx context.Context, input *dynamodb.UpdateItemInput, events []CompletionEvent) error {
+	items := make([]types.TransactWriteItem, 0, len(events)+1)
+	for _, event := range events {
+		item, err := attributevalue.MarshalMap(event)
+		if err != nil {
+			return err
+		}
+
+		items = append(items, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName:           aws.String(r.eventTable),
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(#sequence)"),
+				ExpressionAttributeNames: map[string]string{
+					"#sequence": "sequence",
+				},
+			},
+		})
+	}
+
+	items = append(items, types.TransactWriteItem{
+		Update: &types.Update{
+			TableName:                 input.TableName,
+			Key:                       input.Key,
+			UpdateExpression:          input.UpdateExpression,
+			ConditionExpression:       input.ConditionExpression,
+			ExpressionAttributeNames:  input.ExpressionAttributeNames,
+			ExpressionAttributeValues: input.ExpressionAttributeValues,
+		},
+	})
+
+	_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
+
+	return err
+}
+
+// versionCondition returns the condition of a write which is only made if the
+// goal is still at version, adding the names and values it uses. A goal which
+// has never been changed may have no version stored.
+func versionCondition(version int, names map[string]string, values map[string]types.AttributeValue) *string {
+	names["#version"] = "Version"
+	values[":version"] = &types.AttributeValueMemberN{
+		Value: fmt.Sprintf("%d", version),
+	}
+
+	if version == 0 {
+		return aws.String("attribute_not_exists(#version) OR #version = :version")
+	}
+
+	return aws.String("#version = :version")
+}
+
+// updateGoal applies an update to an existing goal, and increments its version.
+// The update is conditional on the goal existing, so that an update is never
+// able to create a goal. Any condition already on input must also be met, and
+// when the goal exists but that condition is not met, the goal has been changed
+// since it was read. Any events are written in the same transaction as the
+// update, in which case no result is returned.
+func (r *DynamoDBGoalRepository) updateGoal(ctx context.Context, id string, input *dynamodb.UpdateItemInput, events []CompletionEvent) (*dynamodb.UpdateItemOutput, error) {
+	input.TableName = aws.String(r.table)
+	input.Key = r.key(id)
+
+	condition := r.condition(input.ExpressionAttributeNames, input.ExpressionAttributeValues)
+	conditional := input.ConditionExpression != nil
+	if conditional {
+		condition = aws.String(*condition + " AND (" + *input.ConditionExpression + ")")
+	}
+	input.ConditionExpression = condition
+
+	input.UpdateExpression = aws.String(*input.UpdateExpression + " ADD #version :one")
+	input.ExpressionAttributeNames["#version"] = "Version"
+	input.ExpressionAttributeValues[":one"] = &types.AttributeValueMemberN{
+		Value: "1",
+	}
+
+	var (
+		result *dynamodb.UpdateItemOutput
+		err    error
+	)
+	if len(events) > 0 {
+		err = r.transactUpdate(ctx, input, events)
+	} else {
+		result, err = r.client.UpdateItem(ctx, input)
+	}
+
+	if conditionFailed(err) {
+		// The failed update does not say which part of the condition failed, so
+		// the goal is read to tell a missing goal from a changed one.
+		if !conditional {
+			return nil, ErrGoalNotFound
+		}
+
+		if _, err := r.GetGoal(ctx, id); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrVersionConflict
+	}
+
+	return result, err
+}
+
+func (r *DynamoDBGoalRepository) UpdateGoal(ctx context.Context, id string, update GoalUpdate) (Goal, error) {
+	expressions := []string{}
+	removeExpressions := []string{}
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+
+	if update.Title != nil {
+		expressions = append(expressions, "#title = :title")
+		names["#title"] = "Title"
+		values[":title"] = &types.AttributeValueMemberS{
+			Value: *update.Title,
+		}
+	}
+
+	if update.Motivation != nil {
+		expressions = append(expressions, "#motivation = :motivation")
+		names["#motivation"] = "Motivation"
+		values[":motivation"] = &types.AttributeValueMemberS{
+			Value: *update.Motivation,
+		}
+	}
+
+	if update.Target != nil {
+		names["#target"] = "Target"
+		if update.Target.IsEmpty() {
+			removeExpressions = append(removeExpressions, "#target")
+		} else {
+			target, err := attributevalue.Marshal(update.Target)
+			if err != nil {
+				return Goal{}, err
+			}
+
+			expressions = append(expressions, "#target = :target")
+			values[":target"] = target
+		}
+	}
+
+	var events []CompletionEvent
+	if update.Streaks != nil {
+		setStreaks, removeStreaks, err := streakExpressions(*update.Streaks, names, values)
+		if err != nil {
+			return Goal{}, err
+		}
+
+		expressions = append(expressions, setStreaks...)
+		removeExpressions = append(removeExpressions, removeStreaks...)
+		events = update.Streaks.Events
+	}
+
+	if len(expressions) == 0 && len(removeExpressions) == 0 {
+		return r.GetGoal(ctx, id)
+	}
+
+	expression := ""
+	if len(expressions) > 0 {
+		expression = "SET " + strings.Join(expressions, ", ")
+	}
+	if len(removeExpressions) > 0 {
+		expression += " REMOVE " + strings.Join(removeExpressions, ", ")
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		ReturnValues:              types.ReturnValueAllNew,
+		UpdateExpression:          aws.String(strings.TrimSpace(expression)),
+		ConditionExpression:       versionCondition(update.Version, names, values),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}
+
+	result, err := r.updateGoal(ctx, id, input, events)
+	if err != nil {
+		return Goal{}, err
+	}
+
+	// An update written along with events returns no result.
+	if result == nil {
+		return r.GetGoal(ctx, id)
+	}
+
+	var goal Goal
+	if err := attributevalue.UnmarshalMap(result.Attributes, &goal); err != nil {
+		return Goal{}, err
+	}
+
+	return goal, nil
+}
+
+// DeleteGoal deletes the goal before its events, as every write of an event is
+// conditional on the goal existing, so no more events can be written for it
+// once it has been deleted.
+func (r *DynamoDBGoalRepository) DeleteGoal(ctx context.Context, id string) error {
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+
+	input := &dynamodb.DeleteItemInput{
+		TableName:                aws.String(r.table),
+		Key:                      r.key(id),
+		ConditionExpression:      r.condition(names, values),
+		ExpressionAttributeNames: names,
+	}
+	if len(values) > 0 {
+		input.ExpressionAttributeValues = values
+	}
+
+	_, err := r.client.DeleteItem(ctx, input)
+
+	var conditionalCheckFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionalCheckFailed) {
+		return ErrGoalNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return r.deleteCompletionEvents(ctx, id)
+}
+
+// deleteCompletionEvents deletes every event of a goal, a batch at a time.
+func (r *DynamoDBGoalRepository) deleteCompletionEvents(ctx context.Context, id string) error {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.eventTable),
+		KeyConditionExpression: aws.String("#goal = :goal"),
+		ProjectionExpression:   aws.String("#goal, #sequence"),
+		Limit:                  aws.Int32(MAX_BATCH_WRITE_ITEMS),
+		ExpressionAttributeNames: map[string]string{
+			"#goal":     "goal_uuid",
+			"#sequence": "sequence",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":goal": &types.AttributeValueMemberS{
+				Value: id,
+			},
+		},
+	}
+
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		if len(result.Items) > 0 {
+			requests := make([]types.WriteRequest, len(result.Items))
+			for i, key := range result.Items {
+				requests[i] = types.WriteRequest{
+					DeleteRequest: &types.DeleteRequest{
+						Key: key,
+					},
+				}
+			}
+
+			if err := r.batchWrite(ctx, r.eventTable, requests); err != nil {
+				return err
+			}
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+}
+
+// batchWrite makes at most MAX_BATCH_WRITE_ITEMS writes to table, trying again
+// any writes DynamoDB leaves unprocessed.
+func (r *DynamoDBGoalRepository) batchWrite(ctx context.Context, table string, requests []types.WriteRequest) error {
+	for attempt := 0; len(requests) > 0; attempt++ {
+		if attempt == MAX_BATCH_WRITE_ATTEMPTS {
+			return fmt.Errorf("%d writes to '%s' were left unprocessed", len(requests), table)
+		}
+
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * 100 * time.Millisecond):
+			}
+		}
+
+		result, err := r.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{
+				table: requests,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		requests = result.UnprocessedItems[table]
+	}
+
+	return nil
+}
+
+func (r *DynamoDBGoalRepository) SetGoalArchived(ctx context.Context, id string, archived bool, version int) (Goal, error) {
+	names := map[string]string{
+		"#archived": "Archived",
+	}
+	values := map[string]types.AttributeValue{
+		":archived": &types.AttributeValueMemberBOOL{
+			Value: archived,
+		},
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		ReturnValues:              types.ReturnValueAllNew,
+		UpdateExpression:          aws.String("SET #archived = :archived"),
+		ConditionExpression:       versionCondition(version, names, values),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}
+
+	result, err := r.updateGoal(ctx, id, input, nil)
+	if err != nil {
+		return Goal{}, err
+	}
+
+	var goal Goal
+	if err := attributevalue.UnmarshalMap(result.Attributes, &goal); err != nil {
+		return Goal{}, err
+	}
+
+	return goal, nil
+}
+
+func marshalStreak(streak GoalStreak) types.AttributeValue {
+	value := map[string]types.AttributeValue{
+		"Length": &types.AttributeValueMemberN{
+			Value: fmt.Sprintf("%d", streak.Length),
+		},
+	}
+
+	if len(streak.Skipped) > 0 {
+		skipped := make([]types.AttributeValue, len(streak.Skipped))
+		for i, day := range streak.Skipped {
+			skipped[i] = &types.AttributeValueMemberS{
+				Value: day,
+			}
+		}
+		value["Skipped"] = &types.AttributeValueMemberL{
+			Value: skipped,
+		}
+	}
+
+	return &types.AttributeValueMemberM{
+		Value: value,
+	}
+}
+
+// UpdateStreaks writes only the streaks which have changed, unless the update
+// replaces every streak, in which case the whole streaks map is written. The
+// streaks, streak dates and best streak are all written by one UpdateItem, which
+// DynamoDB applies atomically, along with the undo history and any new schedule,
+// and the events of the update in the same transaction.
+// AssignOwner gives a goal which has no owner to owner, such as a goal written
+// before goals belonged to owners, which no owner is able to see until it has
+// been assigned one. It returns ErrVersionConflict if the goal has been given
+// an owner since it was read.
+func (r *DynamoDBGoalRepository) AssignOwner(ctx context.Context, id string, owner string) error {
+	names := map[string]string{
+		"#owner": "Owner",
+	}
+	values := map[string]types.AttributeValue{
+		":assignedOwner": &types.AttributeValueMemberS{
+			Value: owner,
+		},
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		ReturnValues:              types.ReturnValueNone,
+		UpdateExpression:          aws.String("SET #owner = :assignedOwner"),
+		ConditionExpression:       aws.String("attribute_not_exists(#owner)"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}
+
+	_, err := r.updateGoal(ctx, id, input, nil)
+
+	return err
+}
+
+func (r *DynamoDBGoalRepository) UpdateStreaks(ctx context.Context, id string, update StreakUpdate) error {
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{}
+
+	setExpressions, removeExpressions, err := streakExpressions(update, names, values)
+	if err != nil {
+		return err
+	}
+
+	expression := "SET " + strings.Join(setExpressions, ", ")
+	if len(removeExpressions) > 0 {
+		expression += " REMOVE " + strings.Join(removeExpressions, ", ")
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		ReturnValues:              types.ReturnValueNone,
+		UpdateExpression:          aws.String(expression),
+		ConditionExpression:       versionCondition(update.Version, names, values),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	}
+
+	_, err = r.updateGoal(ctx, id, input, update.Events)
+
+	return err
+}
+
+// streakExpressions returns the SET and REMOVE expressions which make update,
+// adding the names and values they use. The version of update is not checked.
+func streakExpressions(update StreakUpdate, names map[string]string, values map[string]types.AttributeValue) ([]string, []string, error) {
+	s, err := attributevalue.MarshalList(update.StreakDates)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The history is stored as an empty list rather than as null when it is
+	// cleared.
+	history := update.UndoHistory
+	if history == nil {
+		history = []StreakSnapshot{}
+	}
+	h, err := attributevalue.Marshal(history)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	setExpressions := []string{
+		"#streakDates = :streakDates",
+		"#bestStreak = :bestStreak",
+		"#undoHistory = :undoHistory",
+	}
+	removeExpressions := []string{}
+	names["#streaksMap"] = "Streaks"
+	names["#streakDates"] = "StreakDates"
+	names["#bestStreak"] = "BestStreak"
+	names["#undoHistory"] = "UndoHistory"
+	values[":streakDates"] = &types.AttributeValueMemberL{
+		Value: s,
+	}
+	values[":bestStreak"] = &types.AttributeValueMemberN{
+		Value: fmt.Sprintf("%d", update.BestStreak),
+	}
+	values[":undoHistory"] = h
+
+	if update.Replace {
+		streaks := map[string]types.AttributeValue{}
+		for streakDate, streak := range update.Set {
+			streaks[streakDate] = marshalStreak(streak)
+		}
+
+		setExpressions = append(setExpressions, "#streaksMap = :streaks")
+		values[":streaks"] = &types.AttributeValueMemberM{
+			Value: streaks,
+		}
+	} else {
+		i := 0
+		for streakDate, streak := range update.Set {
+			setExpressions = append(setExpressions, fmt.Sprintf("#streaksMap.#set%d = :set%d", i, i))
+			names[fmt.Sprintf("#set%d", i)] = streakDate
+			values[fmt.Sprintf(":set%d", i)] = marshalStreak(streak)
+			i++
+		}
+
+		for i, streakDate := range update.Remove {
+			removeExpressions = append(removeExpressions, fmt.Sprintf("#streaksMap.#remove%d", i))
+			names[fmt.Sprintf("#remove%d", i)] = streakDate
+		}
+	}
+
+	if update.Schedule != nil {
+		names["#schedule"] = "Schedule"
+		if update.Schedule.IsEmpty() {
+			removeExpressions = append(removeExpressions, "#schedule")
+		} else {
+			schedule, err := attributevalue.Marshal(update.Schedule)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			setExpressions = append(setExpressions, "#schedule = :schedule")
+			values[":schedule"] = schedule
+		}
+	}
+
+	if len(update.Events) > 0 {
+		setExpressions = append(setExpressions, "#eventCount = :eventCount")
+		names["#eventCount"] = "EventCount"
+		values[":eventCount"] = &types.AttributeValueMemberN{
+			Value: fmt.Sprintf("%d", update.Events[len(update.Events)-1].Sequence),
+		}
+	}
+
+	return setExpressions, removeExpressions, nil
+}
+
+// ListCompletionEvents queries the events of a goal in the order they were
+// written. The page token is the sequence number of the last event of the
+// previous page.
+func (r *DynamoDBGoalRepository) ListCompletionEvents(ctx context.Context, id string, query CompletionEventQuery) (CompletionEventPage, error) {
+	// The goal is read first, so that the events of a goal belonging to another
+	// owner are not listed.
+	if _, err := r.GetGoal(ctx, id); err != nil {
+		return CompletionEventPage{}, err
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.eventTable),
+		KeyConditionExpression: aws.String("#goal = :goal"),
+		Limit:                  aws.Int32(int32(query.PageSize)),
+		ExpressionAttributeNames: map[string]string{
+			"#goal": "goal_uuid",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":goal": &types.AttributeValueMemberS{
+				Value: id,
+			},
+		},
+	}
+
+	if query.PageToken != "" {
+		after, err := strconv.Atoi(query.PageToken)
+		if err != nil || after < 0 {
+			return CompletionEventPage{}, ErrInvalidPageToken
+		}
+
+		input.ExclusiveStartKey = map[string]types.AttributeValue{
+			"goal_uuid": &types.AttributeValueMemberS{
+				Value: id,
+			},
+			"sequence": &types.AttributeValueMemberN{
+				Value: strconv.Itoa(after),
+			},
+		}
+	}
+
+	result, err := r.client.Query(ctx, input)
+	if err != nil {
+		return CompletionEventPage{}, err
+	}
+
+	page := CompletionEventPage{
+		Events: []CompletionEvent{},
+	}
+	if err := attributevalue.UnmarshalListOfMaps(result.Items, &page.Events); err != nil {
+		return CompletionEventPage{}, err
+	}
+
+	if len(result.LastEvaluatedKey) > 0 && len(page.Events) > 0 {
+		page.NextPageToken = strconv.Itoa(page.Events[len(page.Events)-1].Sequence)
+	}
+
+	return page, nil
+}
@@ -0,0 +1,117 @@
+package xeffect
+
+import (
+	"sort"
+)
+
+// BASELINE_ACTOR is the actor of the event recording the days a goal was
+// completed on before its completions were first recorded as events.
+const BASELINE_ACTOR = "baseline"
+
+// CompletionEvent records the days of a goal being marked as completed, or as
+// not completed. Events are never changed once written, and are numbered in the
+// order they were made, starting from 1, so that the streaks of a goal can be
+// rebuilt by replaying its events in order.
+type CompletionEvent struct {
+	GoalUuid  string   `json:"goal_uuid" dynamodbav:"goal_uuid"`
+	Sequence  int      `json:"sequence" dynamodbav:"sequence"`
+	Dates     []string `json:"dates"`
+	Completed bool     `json:"completed"`
+	// Timestamp is when the event was made, in RFC 3339 format.
+	Timestamp string `json:"timestamp"`
+	// Actor is the user, or API key, the event was made by.
+	Actor string `json:"actor"`
+	// Undo is set when the event was made by undoing an earlier change.
+	Undo bool `json:"undo,omitempty" dynamodbav:",omitempty"`
+}
+
+// CompletionEventQuery selects a page of the events of a goal to be listed.
+type CompletionEventQuery struct {
+	PageSize int
+	// PageToken is the NextPageToken of the previous page, or empty for the first
+	// page.
+	PageToken string
+}
+
+// CompletionEventPage is a single page of events, ordered oldest first.
+// NextPageToken is only set when there are more events to be listed.
+type CompletionEventPage struct {
+	Events        []CompletionEvent
+	NextPageToken string
+}
+
+// LogCompletion returns the events to be written along with a streak update
+// made by changes, numbered to follow the events already written for goal. A
+// goal which was completed before its events were recorded is first given a
+// baseline event, so that replaying its events gives every completed day.
+func LogCompletion(goal Goal, changes ...CompletionEvent) ([]CompletionEvent, error) {
+	events := []CompletionEvent{}
+	if len(changes) == 0 {
+		return events, nil
+	}
+	sequence := goal.EventCount
+
+	if goal.EventCount == 0 {
+		days, err := CompletedDays(goal)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(days) > 0 {
+			dates := make([]string, 0, len(days))
+			for date := range days {
+				dates = append(dates, date)
+			}
+			sort.Strings(dates)
+
+			sequence++
+			events = append(events, CompletionEvent{
+				GoalUuid:  goal.Uuid,
+				Sequence:  sequence,
+				Dates:     dates,
+				Completed: true,
+				Timestamp: changes[0].Timestamp,
+				Actor:     BASELINE_ACTOR,
+			})
+		}
+	}
+
+	for _, event := range changes {
+		sequence++
+		event.GoalUuid = goal.Uuid
+		event.Sequence = sequence
+		event.Dates = append([]string{}, event.Dates...)
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// ReplayCompletionEvents returns the days on which a goal is completed once
+// events have been applied in order.
+func ReplayCompletionEvents(events []CompletionEvent) map[string]bool {
+	ordered := append([]CompletionEvent{}, events...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Sequence < ordered[j].Sequence
+	})
+
+	days := map[string]bool{}
+	for _, event := range ordered {
+		for _, date := range event.Dates {
+			if event.Completed {
+				days[date] = true
+			} else {
+				delete(days, date)
+			}
+		}
+	}
+
+	return days
+}
+
+// copyCompletionEvent returns a copy of event which shares no slices with it.
+func copyCompletionEvent(event CompletionEvent) CompletionEvent {
+	event.Dates = append([]string{}, event.Dates...)
+
+	return event
+}
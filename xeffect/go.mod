@@ -0,0 +1,21 @@
+module github.com/maxstanley/xeffect_backend/xeffect
+
+go 1.17
+
+require (
+	github.com/aws/aws-lambda-go v1.27.1
+	github.com/aws/aws-sdk-go-v2 v1.11.2
+	github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue v1.4.5
+	github.com/aws/aws-sdk-go-v2/service/dynamodb v1.11.0
+	github.com/google/uuid v1.3.0
+)
+
+require (
+	github.com/aws/aws-sdk-go-v2/internal/configsources v1.1.2 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/endpoints/v2 v2.0.2 // indirect
+	github.com/aws/aws-sdk-go-v2/service/dynamodbstreams v1.9.0 // indirect
+	github.com/aws/aws-sdk-go-v2/service/internal/accept-encoding v1.5.0 // indirect
+	github.com/aws/aws-sdk-go-v2/service/internal/endpoint-discovery v1.3.3 // indirect
+	github.com/aws/smithy-go v1.9.0 // indirect
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
+)
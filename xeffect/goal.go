@@ -0,0 +1,141 @@
+package xeffect
+
+// Goal is a goal as it is stored, along with the streaks of days on which it
+// has been completed. Version is incremented by every change to the goal, so
+// that a change decided on from an older version can be detected. EventCount is
+// the number of completion events written for the goal. UndoHistory holds the
+// streaks as they were before each of the most recent changes to them, oldest
+// first.
+type Goal struct {
+	Uuid        string                `json:"uuid" dynamodbav:"uuid"`
+	Title       string                `json:"title"`
+	Motivation  string                `json:"motivation"`
+	BestStreak  int                   `json:"best_streak"`
+	Streaks     map[string]GoalStreak `json:"streaks"`
+	StreakDates []string              `json:"streak_dates"`
+	CreatedAt   string                `json:"created_at"`
+	Archived    bool                  `json:"archived"`
+	Owner       string                `json:"-" dynamodbav:",omitempty"`
+	Version     int                   `json:"version"`
+	EventCount  int                   `json:"-"`
+	UndoHistory []StreakSnapshot      `json:"-" dynamodbav:",omitempty"`
+	Schedule    *Schedule             `json:"schedule" dynamodbav:",omitempty"`
+	Target      *Target               `json:"target" dynamodbav:",omitempty"`
+}
+
+// GoalStreak is a run of consecutive days on which a goal was completed. It is
+// keyed by the date of its first day. The streak of a goal with a schedule may
+// skip days the goal did not need to be completed on, which are listed in order
+// in Skipped, and are counted in its length along with the days completed.
+type GoalStreak struct {
+	Length  int               `json:"streak_length"`
+	Partial map[string]string `json:"partial" dynamodbav:",omitempty"`
+	Skipped []string          `json:"skipped,omitempty" dynamodbav:",omitempty"`
+}
+
+// StreakSnapshot holds the streaks of a goal as they were before a change, so
+// that the change can be undone.
+type StreakSnapshot struct {
+	Streaks     map[string]GoalStreak
+	StreakDates []string
+	BestStreak  int
+}
+
+// GoalUpdate holds the fields of a goal to be changed. Fields left nil are not
+// changed, and an empty Target removes the target of the goal.
+type GoalUpdate struct {
+	Title      *string
+	Motivation *string
+	Target     *Target
+	// Streaks changes the streaks of the goal in the same write when set, such
+	// as when its schedule is changed along with its other fields. The version
+	// of the update is used rather than the version of Streaks.
+	Streaks *StreakUpdate
+	// Version is the version of the goal the update was decided on from. The
+	// update is only made if the goal is still at that version.
+	Version int
+}
+
+// StreakUpdate describes a change to the streaks of a goal.
+type StreakUpdate struct {
+	// Set holds the streaks to be created or replaced, keyed by start date.
+	Set map[string]GoalStreak
+	// Remove holds the start dates of the streaks to be removed.
+	Remove []string
+	// Replace is set when Set holds every streak of the goal, so any streak not
+	// in Set is removed.
+	Replace bool
+	// StreakDates is the start date of every streak once the update has been
+	// made, ordered most recent first.
+	StreakDates []string
+	BestStreak  int
+	// Version is the version of the goal the update was decided on from. The
+	// update is only made if the goal is still at that version.
+	Version int
+	// Events are the completion events which caused the update, as returned by
+	// LogCompletion. They are written along with the update, or not at all.
+	Events []CompletionEvent
+	// UndoHistory replaces the undo history of the goal. An update which leaves
+	// it empty clears the history.
+	UndoHistory []StreakSnapshot
+	// Schedule replaces the schedule of the goal when set, and must be the
+	// schedule Set was laid out for. An empty schedule removes the schedule, so
+	// that the goal is to be completed every day.
+	Schedule *Schedule
+}
+
+// GoalQuery selects a page of goals to be listed.
+type GoalQuery struct {
+	IncludeArchived bool
+	PageSize        int
+	// PageToken is the NextPageToken of the previous page, or empty for the first
+	// page.
+	PageToken string
+}
+
+// GoalPage is a single page of goals. NextPageToken is only set when there are
+// more goals to be listed.
+type GoalPage struct {
+	Goals         []Goal
+	NextPageToken string
+}
+
+// copyStreaks returns a copy of streaks which shares no maps with it.
+func copyStreaks(streaks map[string]GoalStreak) map[string]GoalStreak {
+	copied := make(map[string]GoalStreak, len(streaks))
+	for streakDate, streak := range streaks {
+		copied[streakDate] = streak
+	}
+
+	return copied
+}
+
+// copyUndoHistory returns a copy of history which shares no maps or slices with
+// it.
+func copyUndoHistory(history []StreakSnapshot) []StreakSnapshot {
+	if history == nil {
+		return nil
+	}
+
+	copied := make([]StreakSnapshot, len(history))
+	for i, snapshot := range history {
+		copied[i] = StreakSnapshot{
+			Streaks:     copyStreaks(snapshot.Streaks),
+			StreakDates: append([]string{}, snapshot.StreakDates...),
+			BestStreak:  snapshot.BestStreak,
+		}
+	}
+
+	return copied
+}
+
+// copyGoal returns a copy of goal which shares no maps or slices with it.
+func copyGoal(goal Goal) Goal {
+	goal.Streaks = copyStreaks(goal.Streaks)
+	goal.StreakDates = append([]string{}, goal.StreakDates...)
+	goal.UndoHistory = copyUndoHistory(goal.UndoHistory)
+	goal.Schedule = copySchedule(goal.Schedule)
+	goal.Target = copyTarget(goal.Target)
+
+	return goal
+}
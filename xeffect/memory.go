@@ -0,0 +1,250 @@
+package xeffect
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// MemoryGoalRepository stores goals in memory, and is safe for concurrent use.
+// Goals are copied on the way in and out, so callers never share state with the
+// repository.
+type MemoryGoalRepository struct {
+	mu     *sync.Mutex
+	goals  map[string]Goal
+	events map[string][]CompletionEvent
+	owner  string
+}
+
+func NewMemoryGoalRepository() *MemoryGoalRepository {
+	return &MemoryGoalRepository{
+		mu:     &sync.Mutex{},
+		goals:  map[string]Goal{},
+		events: map[string][]CompletionEvent{},
+	}
+}
+
+// ForOwner returns a repository sharing the goals and events of r.
+func (r *MemoryGoalRepository) ForOwner(owner string) GoalRepository {
+	return &MemoryGoalRepository{
+		mu:     r.mu,
+		goals:  r.goals,
+		events: r.events,
+		owner:  owner,
+	}
+}
+
+// goal returns the goal with the given id, if it can be seen by the owner of
+// the repository. The lock must be held.
+func (r *MemoryGoalRepository) goal(id string) (Goal, bool) {
+	goal, ok := r.goals[id]
+	if !ok || (r.owner != "" && goal.Owner != r.owner) {
+		return Goal{}, false
+	}
+
+	return goal, true
+}
+
+func (r *MemoryGoalRepository) CreateGoal(ctx context.Context, goal Goal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.owner != "" {
+		goal.Owner = r.owner
+	}
+	r.goals[goal.Uuid] = copyGoal(goal)
+
+	return nil
+}
+
+func (r *MemoryGoalRepository) GetGoal(ctx context.Context, id string) (Goal, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	goal, ok := r.goal(id)
+	if !ok {
+		return Goal{}, ErrGoalNotFound
+	}
+
+	return copyGoal(goal), nil
+}
+
+// ListGoals lists the goals ordered by uuid. The page token is the position in
+// that order the page starts at.
+func (r *MemoryGoalRepository) ListGoals(ctx context.Context, query GoalQuery) (GoalPage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start := 0
+	if query.PageToken != "" {
+		var err error
+		start, err = strconv.Atoi(query.PageToken)
+		if err != nil || start < 0 {
+			return GoalPage{}, ErrInvalidPageToken
+		}
+	}
+
+	ids := make([]string, 0, len(r.goals))
+	for id := range r.goals {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	page := GoalPage{
+		Goals: []Goal{},
+	}
+
+	for i := start; i < len(ids); i++ {
+		if len(page.Goals) == query.PageSize {
+			page.NextPageToken = strconv.Itoa(i)
+			break
+		}
+
+		goal, ok := r.goal(ids[i])
+		if !ok || (goal.Archived && !query.IncludeArchived) {
+			continue
+		}
+
+		page.Goals = append(page.Goals, copyGoal(goal))
+	}
+
+	return page, nil
+}
+
+func (r *MemoryGoalRepository) UpdateGoal(ctx context.Context, id string, update GoalUpdate) (Goal, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	goal, ok := r.goal(id)
+	if !ok {
+		return Goal{}, ErrGoalNotFound
+	}
+
+	if goal.Version != update.Version {
+		return Goal{}, ErrVersionConflict
+	}
+
+	if update.Title != nil {
+		goal.Title = *update.Title
+	}
+
+	if update.Motivation != nil {
+		goal.Motivation = *update.Motivation
+	}
+
+	if update.Target != nil {
+		goal.Target = copyTarget(update.Target)
+	}
+
+	if update.Streaks != nil {
+		// ApplyStreakUpdate increments the version itself.
+		goal = ApplyStreakUpdate(goal, *update.Streaks)
+		for _, event := range update.Streaks.Events {
+			r.events[id] = append(r.events[id], copyCompletionEvent(event))
+		}
+	} else {
+		goal.Version++
+	}
+
+	r.goals[id] = goal
+
+	return copyGoal(goal), nil
+}
+
+func (r *MemoryGoalRepository) DeleteGoal(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.goal(id); !ok {
+		return ErrGoalNotFound
+	}
+
+	delete(r.goals, id)
+	delete(r.events, id)
+
+	return nil
+}
+
+func (r *MemoryGoalRepository) SetGoalArchived(ctx context.Context, id string, archived bool, version int) (Goal, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	goal, ok := r.goal(id)
+	if !ok {
+		return Goal{}, ErrGoalNotFound
+	}
+
+	if goal.Version != version {
+		return Goal{}, ErrVersionConflict
+	}
+
+	goal.Archived = archived
+	goal.Version++
+	r.goals[id] = goal
+
+	return copyGoal(goal), nil
+}
+
+func (r *MemoryGoalRepository) UpdateStreaks(ctx context.Context, id string, update StreakUpdate) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	goal, ok := r.goal(id)
+	if !ok {
+		return ErrGoalNotFound
+	}
+
+	if goal.Version != update.Version {
+		return ErrVersionConflict
+	}
+
+	// ApplyStreakUpdate copies the stored goal before changing it, so that a goal
+	// previously returned by the repository is not changed with it.
+	r.goals[id] = ApplyStreakUpdate(goal, update)
+	for _, event := range update.Events {
+		r.events[id] = append(r.events[id], copyCompletionEvent(event))
+	}
+
+	return nil
+}
+
+// ListCompletionEvents lists the events of a goal in the order they were
+// written. The page token is the sequence number of the last event of the
+// previous page.
+func (r *MemoryGoalRepository) ListCompletionEvents(ctx context.Context, id string, query CompletionEventQuery) (CompletionEventPage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.goal(id); !ok {
+		return CompletionEventPage{}, ErrGoalNotFound
+	}
+
+	after := 0
+	if query.PageToken != "" {
+		var err error
+		after, err = strconv.Atoi(query.PageToken)
+		if err != nil || after < 0 {
+			return CompletionEventPage{}, ErrInvalidPageToken
+		}
+	}
+
+	page := CompletionEventPage{
+		Events: []CompletionEvent{},
+	}
+
+	for _, event := range r.events[id] {
+		if event.Sequence <= after {
+			continue
+		}
+
+		if len(page.Events) == query.PageSize {
+			page.NextPageToken = strconv.Itoa(page.Events[len(page.Events)-1].Sequence)
+			break
+		}
+
+		page.Events = append(page.Events, copyCompletionEvent(event))
+	}
+
+	return page, nil
+}
@@ -0,0 +1,94 @@
+package xeffect
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMemoryGoalRepositoryVersion(t *testing.T) {
+	ctx := context.Background()
+	goals := NewMemoryGoalRepository()
+
+	if err := goals.CreateGoal(ctx, Goal{Uuid: "goal-1", Title: "Read"}); err != nil {
+		t.Fatal(err)
+	}
+
+	title := "Write"
+	goal, err := goals.UpdateGoal(ctx, "goal-1", GoalUpdate{Title: &title, Version: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if goal.Title != title || goal.Version != 1 {
+		t.Fatalf("the goal is %+v after the update", goal)
+	}
+
+	// Every other write decided on from the first version now conflicts.
+	if _, err := goals.UpdateGoal(ctx, "goal-1", GoalUpdate{Title: &title, Version: 0}); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("UpdateGoal returned %v, want ErrVersionConflict", err)
+	}
+
+	if _, err := goals.SetGoalArchived(ctx, "goal-1", true, 0); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("SetGoalArchived returned %v, want ErrVersionConflict", err)
+	}
+
+	if err := goals.UpdateStreaks(ctx, "goal-1", StreakUpdate{Replace: true, Version: 0}); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("UpdateStreaks returned %v, want ErrVersionConflict", err)
+	}
+
+	goal, err = goals.SetGoalArchived(ctx, "goal-1", true, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !goal.Archived || goal.Version != 2 {
+		t.Fatalf("the goal is %+v after being archived", goal)
+	}
+
+	if _, err := goals.SetGoalArchived(ctx, "goal-2", true, 0); !errors.Is(err, ErrGoalNotFound) {
+		t.Errorf("SetGoalArchived returned %v for a missing goal, want ErrGoalNotFound", err)
+	}
+}
+
+func TestMemoryGoalRepositoryDeleteGoal(t *testing.T) {
+	ctx := context.Background()
+	goals := NewMemoryGoalRepository()
+
+	if err := goals.CreateGoal(ctx, Goal{Uuid: "goal-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := LogCompletion(Goal{Uuid: "goal-1"}, CompletionEvent{Dates: []string{"2021-12-01"}, Completed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	update := StreakUpdate{
+		Set:         map[string]GoalStreak{"2021-12-01": {Length: 1}},
+		Replace:     true,
+		StreakDates: []string{"2021-12-01"},
+		Events:      events,
+	}
+	if err := goals.UpdateStreaks(ctx, "goal-1", update); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := goals.DeleteGoal(ctx, "goal-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A goal created again with the same id starts without any events.
+	if err := goals.CreateGoal(ctx, Goal{Uuid: "goal-1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	page, err := goals.ListCompletionEvents(ctx, "goal-1", CompletionEventQuery{PageSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(page.Events) != 0 {
+		t.Errorf("the deleted goal left %d events", len(page.Events))
+	}
+}
@@ -0,0 +1,33 @@
+package xeffect
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// RequestOwner returns the user a request was made by, as identified by the API
+// Gateway authorizer. The subject claim of a verified token is preferred, with
+// the principal of a custom authorizer used otherwise.
+func RequestOwner(requestContext events.APIGatewayProxyRequestContext) (string, bool) {
+	if claims, ok := requestContext.Authorizer["claims"].(map[string]interface{}); ok {
+		if subject, ok := claims["sub"].(string); ok && subject != "" {
+			return subject, true
+		}
+	}
+
+	if principal, ok := requestContext.Authorizer["principalId"].(string); ok && principal != "" {
+		return principal, true
+	}
+
+	return "", false
+}
+
+// RequestActor returns who a request was made by, for recording alongside the
+// changes it makes. A request made with an API key is attributed to the key,
+// and any other request to its owner.
+func RequestActor(requestContext events.APIGatewayProxyRequestContext) (string, bool) {
+	if id, ok := requestContext.Authorizer["api_key_id"].(string); ok && id != "" {
+		return "api_key:" + id, true
+	}
+
+	return RequestOwner(requestContext)
+}
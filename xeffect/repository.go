@@ -0,0 +1,42 @@
+package xeffect
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrGoalNotFound     = errors.New("goal not found")
+	ErrInvalidPageToken = errors.New("invalid page token")
+	ErrVersionConflict  = errors.New("goal has been changed since it was read")
+)
+
+// GoalRepository stores goals and their streaks. Methods given the id of a goal
+// which does not exist return ErrGoalNotFound, and never create the goal.
+//
+// A repository returned by ForOwner only sees the goals of that owner. Goals
+// of any other owner are treated as if they do not exist, and goals it creates
+// are given to the owner.
+type GoalRepository interface {
+	ForOwner(owner string) GoalRepository
+	CreateGoal(ctx context.Context, goal Goal) error
+	GetGoal(ctx context.Context, id string) (Goal, error)
+	ListGoals(ctx context.Context, query GoalQuery) (GoalPage, error)
+	// UpdateGoal changes the fields of a goal, along with its streaks when the
+	// update has any, and returns the goal as it is after the update. Every
+	// change is made at once, or none of them. It returns ErrVersionConflict if the goal has changed
+	// since the version the update was decided on from.
+	UpdateGoal(ctx context.Context, id string, update GoalUpdate) (Goal, error)
+	// DeleteGoal deletes a goal along with its completion events.
+	DeleteGoal(ctx context.Context, id string) error
+	// SetGoalArchived archives or restores a goal at the given version, and
+	// returns the goal as it is after the change. It returns ErrVersionConflict
+	// if the goal is no longer at that version.
+	SetGoalArchived(ctx context.Context, id string, archived bool, version int) (Goal, error)
+	// UpdateStreaks makes every change of update at once, or none of them. It
+	// returns ErrVersionConflict if the goal has changed since the version the
+	// update was decided on from.
+	UpdateStreaks(ctx context.Context, id string, update StreakUpdate) error
+	// ListCompletionEvents lists the completion events of a goal, oldest first.
+	ListCompletionEvents(ctx context.Context, id string, query CompletionEventQuery) (CompletionEventPage, error)
+}
@@ -0,0 +1,42 @@
+package xeffect
+
+import (
+	"encoding/json"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// ErrorResponse is the body of every JSON error response. Code identifies the
+// error for clients, and Message describes it for people.
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSONErrorResponse returns a response with the given status code and an
+// ErrorResponse body.
+func JSONErrorResponse(statusCode int, code string, message string) (events.APIGatewayProxyResponse, error) {
+	body, err := json.Marshal(ErrorResponse{
+		Code:    code,
+		Message: message,
+	})
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode:      statusCode,
+		IsBase64Encoded: false,
+		Headers: map[string]string{
+			"Content-Type":                "application/json",
+			"Access-Control-Allow-Origin": "*",
+		},
+		Body: string(body),
+	}, nil
+}
+
+// UnauthorizedResponse returns the response to a request which does not
+// identify the user it was made by.
+func UnauthorizedResponse() (events.APIGatewayProxyResponse, error) {
+	return JSONErrorResponse(401, "unauthorized", "The request does not identify a user.")
+}
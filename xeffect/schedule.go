@@ -0,0 +1,210 @@
+package xeffect
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MAX_EVERY_DAYS is the longest interval between the days of a schedule which
+// repeats every so many days.
+const MAX_EVERY_DAYS = 366
+
+// Schedule describes the days on which a goal is to be completed, when it is
+// not every day. Exactly one of Weekdays, EveryDays or TimesPerWeek is set.
+type Schedule struct {
+	// Weekdays are the days of the week the goal is to be completed on, such as
+	// "monday".
+	Weekdays []string `json:"weekdays,omitempty" dynamodbav:",omitempty"`
+	// EveryDays is the number of days between the days the goal is to be
+	// completed on, counting from StartDate.
+	EveryDays int    `json:"every_days,omitempty" dynamodbav:",omitempty"`
+	StartDate string `json:"start_date,omitempty" dynamodbav:",omitempty"`
+	// TimesPerWeek is the number of days the goal is to be completed on each
+	// week, on any days of the week. Weeks start on a Monday.
+	TimesPerWeek int `json:"times_per_week,omitempty" dynamodbav:",omitempty"`
+}
+
+// IsEmpty reports whether none of the fields of the schedule are set.
+func (s *Schedule) IsEmpty() bool {
+	return len(s.Weekdays) == 0 && s.EveryDays == 0 && s.StartDate == "" && s.TimesPerWeek == 0
+}
+
+// copySchedule returns a copy of schedule which shares no slices with it, or nil
+// when schedule is nil or empty.
+func copySchedule(schedule *Schedule) *Schedule {
+	if schedule == nil || schedule.IsEmpty() {
+		return nil
+	}
+
+	copied := *schedule
+	copied.Weekdays = append([]string{}, schedule.Weekdays...)
+
+	return &copied
+}
+
+// Validate returns an error describing why the schedule can not be used, or nil
+// when it can.
+func (s *Schedule) Validate() error {
+	set := 0
+	if len(s.Weekdays) > 0 {
+		set++
+	}
+	if s.EveryDays != 0 {
+		set++
+	}
+	if s.TimesPerWeek != 0 {
+		set++
+	}
+	if set != 1 {
+		return errors.New("a schedule must have exactly one of 'weekdays', 'every_days' or 'times_per_week'")
+	}
+
+	seen := map[time.Weekday]bool{}
+	for _, weekday := range s.Weekdays {
+		day, ok := parseWeekday(weekday)
+		if !ok {
+			return fmt.Errorf("'%s' is not a day of the week", weekday)
+		}
+
+		if seen[day] {
+			return fmt.Errorf("'%s' is given more than once", weekday)
+		}
+		seen[day] = true
+	}
+
+	if s.EveryDays < 0 || s.EveryDays > MAX_EVERY_DAYS {
+		return fmt.Errorf("'every_days' must be between 1 and %d", MAX_EVERY_DAYS)
+	}
+
+	if s.EveryDays != 0 {
+		if _, err := time.Parse("2006-01-02", s.StartDate); err != nil {
+			return fmt.Errorf("'start_date' must be a date when 'every_days' is given: %w", err)
+		}
+	} else if s.StartDate != "" {
+		return errors.New("'start_date' may only be given with 'every_days'")
+	}
+
+	if s.TimesPerWeek < 0 || s.TimesPerWeek > 7 {
+		return errors.New("'times_per_week' must be between 1 and 7")
+	}
+
+	return nil
+}
+
+func parseWeekday(weekday string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), weekday) {
+			return day, true
+		}
+	}
+
+	return 0, false
+}
+
+// weekStart returns the Monday of the week date falls in.
+func weekStart(date time.Time) time.Time {
+	return date.AddDate(0, 0, -(int(date.Weekday())+6)%7)
+}
+
+// Scheduled reports whether the goal is to be completed on date. Every day is
+// scheduled by a goal without a schedule, and by one completed a number of
+// times each week, as the goal may be completed on any of them.
+func (s *Schedule) Scheduled(date time.Time) bool {
+	if s == nil {
+		return true
+	}
+
+	if len(s.Weekdays) > 0 {
+		for _, weekday := range s.Weekdays {
+			if day, ok := parseWeekday(weekday); ok && day == date.Weekday() {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if s.EveryDays > 0 {
+		start, err := time.Parse("2006-01-02", s.StartDate)
+		if err != nil {
+			return true
+		}
+
+		days := int(date.Sub(start).Hours() / 24)
+		return (days%s.EveryDays+s.EveryDays)%s.EveryDays == 0
+	}
+
+	return true
+}
+
+// completedInWeek counts the days completed in the week date falls in.
+func completedInWeek(date time.Time, days map[string]bool) int {
+	start := weekStart(date)
+
+	completed := 0
+	for i := 0; i < 7; i++ {
+		if days[start.AddDate(0, 0, i).Format("2006-01-02")] {
+			completed++
+		}
+	}
+
+	return completed
+}
+
+// Skippable reports whether the goal may go without being completed on date
+// without breaking a streak, given the days on which it was completed. Days
+// which are not scheduled may be skipped, as may every day of a week in which
+// a goal completed a number of times each week reached that number.
+func (s *Schedule) Skippable(date time.Time, days map[string]bool) bool {
+	if s == nil {
+		return false
+	}
+
+	if s.TimesPerWeek > 0 {
+		return completedInWeek(date, days) >= s.TimesPerWeek
+	}
+
+	return !s.Scheduled(date)
+}
+
+// missed reports whether a goal which was not completed on date had missed it
+// as of asOf, given the days on which it was completed. The date asOf itself is
+// never missed, as it may still be completed, and neither is a day of the week
+// of asOf while there are enough days left in it for a goal completed a number
+// of times each week to reach that number.
+func (s *Schedule) missed(date time.Time, asOf time.Time, days map[string]bool) bool {
+	if days[date.Format("2006-01-02")] || !date.Before(asOf) || s.Skippable(date, days) {
+		return false
+	}
+
+	if s != nil && s.TimesPerWeek > 0 && weekStart(date).Equal(weekStart(asOf)) {
+		daysLeft := 7 - (int(asOf.Weekday())+6)%7
+		return completedInWeek(date, days)+daysLeft < s.TimesPerWeek
+	}
+
+	return true
+}
+
+// expectedDays returns the number of days a goal was to be completed on between
+// two dates inclusive.
+func (s *Schedule) expectedDays(from time.Time, to time.Time) float64 {
+	days := int(to.Sub(from).Hours()/24) + 1
+	if days <= 0 {
+		return 0
+	}
+
+	if s != nil && s.TimesPerWeek > 0 {
+		return float64(days*s.TimesPerWeek) / 7
+	}
+
+	expected := 0
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		if s.Scheduled(date) {
+			expected++
+		}
+	}
+
+	return float64(expected)
+}
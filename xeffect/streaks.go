@@ -0,0 +1,271 @@
+package xeffect
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// CompletedDays expands the streaks of a goal into the set of days on which it
+// was completed, leaving out the days each streak skipped.
+func CompletedDays(goal Goal) (map[string]bool, error) {
+	days := map[string]bool{}
+	for streakDate, streak := range goal.Streaks {
+		date, err := time.Parse("2006-01-02", streakDate)
+		if err != nil {
+			return nil, err
+		}
+
+		skipped := map[string]bool{}
+		for _, day := range streak.Skipped {
+			skipped[day] = true
+		}
+
+		for i := 0; i < streak.Length; i++ {
+			if day := date.AddDate(0, 0, i).Format("2006-01-02"); !skipped[day] {
+				days[day] = true
+			}
+		}
+	}
+
+	return days, nil
+}
+
+// skippedBetween returns the days between two dates, exclusive, when the goal
+// may skip every one of them without breaking a streak, given the days on which
+// it was completed.
+func skippedBetween(schedule *Schedule, earlier time.Time, later time.Time, days map[string]bool) ([]string, bool) {
+	skipped := []string{}
+	for date := earlier.AddDate(0, 0, 1); date.Before(later); date = date.AddDate(0, 0, 1) {
+		if days[date.Format("2006-01-02")] || !schedule.Skippable(date, days) {
+			return nil, false
+		}
+
+		skipped = append(skipped, date.Format("2006-01-02"))
+	}
+
+	return skipped, true
+}
+
+// StreaksFromDays groups a set of completed days into streaks, following the
+// schedule of the goal, which is nil for a goal completed every day. The streaks
+// are returned keyed by their start date, along with the start dates ordered
+// most recent first.
+func StreaksFromDays(days map[string]bool, schedule *Schedule) (map[string]GoalStreak, []string, error) {
+	dates := make([]string, 0, len(days))
+	for date := range days {
+		dates = append(dates, date)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dates)))
+
+	streaks := map[string]GoalStreak{}
+	streakDates := []string{}
+
+	// Working backwards through the days, a day either starts a new streak, or
+	// becomes the new start of the streak which began after it, along with any
+	// days skipped in between.
+	var previousDate time.Time
+	for _, day := range dates {
+		date, err := time.Parse("2006-01-02", day)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		skipped, ok := skippedBetween(schedule, date, previousDate, days)
+		if len(streakDates) > 0 && ok {
+			streakDate := streakDates[len(streakDates)-1]
+			streak := streaks[streakDate]
+			streak.Length += len(skipped) + 1
+			if len(skipped) > 0 {
+				streak.Skipped = append(skipped, streak.Skipped...)
+			}
+
+			delete(streaks, streakDate)
+			streaks[day] = streak
+			streakDates[len(streakDates)-1] = day
+		} else {
+			streaks[day] = GoalStreak{Length: 1}
+			streakDates = append(streakDates, day)
+		}
+
+		previousDate = date
+	}
+
+	return streaks, streakDates, nil
+}
+
+// CheckStreaks returns a description of every way in which the streaks of a
+// goal are inconsistent, or nothing when they are consistent. The streaks are
+// consistent when the streak dates are the start of every streak, ordered most
+// recent first, every streak is at least a day long, only skips days within it
+// which the schedule of the goal allows to be skipped, no two streaks overlap or
+// should have been merged, and the best streak is the longest streak.
+func CheckStreaks(goal Goal) []string {
+	issues := []string{}
+
+	// Whether a day may be skipped can depend on the other days completed, so
+	// any streaks which do not start on a date are left out of them.
+	days := map[string]bool{}
+	if completed, err := CompletedDays(goal); err == nil {
+		days = completed
+	}
+
+	for i, streakDate := range goal.StreakDates {
+		if _, err := time.Parse("2006-01-02", streakDate); err != nil {
+			issues = append(issues, fmt.Sprintf("streak date '%s' is not a date", streakDate))
+		}
+
+		if i > 0 && streakDate >= goal.StreakDates[i-1] {
+			issues = append(issues, fmt.Sprintf("streak date '%s' is not before '%s'", streakDate, goal.StreakDates[i-1]))
+		}
+
+		if _, ok := goal.Streaks[streakDate]; !ok {
+			issues = append(issues, fmt.Sprintf("streak date '%s' has no streak", streakDate))
+		}
+	}
+
+	listed := map[string]bool{}
+	for _, streakDate := range goal.StreakDates {
+		listed[streakDate] = true
+	}
+
+	starts := make([]string, 0, len(goal.Streaks))
+	for streakDate := range goal.Streaks {
+		starts = append(starts, streakDate)
+	}
+	sort.Strings(starts)
+
+	best := 0
+	var (
+		previousDate string
+		previousEnd  time.Time
+	)
+	for _, streakDate := range starts {
+		streak := goal.Streaks[streakDate]
+
+		if !listed[streakDate] {
+			issues = append(issues, fmt.Sprintf("streak '%s' is not in the streak dates", streakDate))
+		}
+
+		if streak.Length <= 0 {
+			issues = append(issues, fmt.Sprintf("streak '%s' has a length of %d", streakDate, streak.Length))
+			continue
+		}
+
+		if streak.Length > best {
+			best = streak.Length
+		}
+
+		start, err := time.Parse("2006-01-02", streakDate)
+		if err != nil {
+			issues = append(issues, fmt.Sprintf("streak '%s' does not start on a date", streakDate))
+			continue
+		}
+		end := start.AddDate(0, 0, streak.Length)
+
+		previousSkipped := streakDate
+		for _, day := range streak.Skipped {
+			date, err := time.Parse("2006-01-02", day)
+			switch {
+			case err != nil:
+				issues = append(issues, fmt.Sprintf("streak '%s' skips '%s', which is not a date", streakDate, day))
+			case day <= previousSkipped || !date.Before(end.AddDate(0, 0, -1)):
+				issues = append(issues, fmt.Sprintf("streak '%s' skips '%s', which is out of order or not between its first and last days", streakDate, day))
+			case !goal.Schedule.Skippable(date, days):
+				issues = append(issues, fmt.Sprintf("streak '%s' skips '%s', which the schedule does not allow to be skipped", streakDate, day))
+			}
+			previousSkipped = day
+		}
+
+		// Streaks are compared with the streak before them, which always starts
+		// first, as the start dates are sorted.
+		if previousDate != "" {
+			if start.Before(previousEnd) {
+				issues = append(issues, fmt.Sprintf("streak '%s' overlaps streak '%s'", streakDate, previousDate))
+			} else if start.Equal(previousEnd) {
+				issues = append(issues, fmt.Sprintf("streak '%s' starts the day after streak '%s' ends, so should be merged with it", streakDate, previousDate))
+			} else if _, ok := skippedBetween(goal.Schedule, previousEnd.AddDate(0, 0, -1), start, days); ok {
+				issues = append(issues, fmt.Sprintf("streak '%s' is only separated from streak '%s' by days which may be skipped, so should be merged with it", streakDate, previousDate))
+			}
+		}
+
+		if previousDate == "" || end.After(previousEnd) {
+			previousDate, previousEnd = streakDate, end
+		}
+	}
+
+	if goal.BestStreak != best {
+		issues = append(issues, fmt.Sprintf("best streak is %d, but the longest streak is %d", goal.BestStreak, best))
+	}
+
+	return issues
+}
+
+// NormaliseStreaks returns the update which replaces the streaks of a goal with
+// a consistent layout of the same completed days. Streaks without a positive
+// length hold no completed days, so are dropped.
+func NormaliseStreaks(goal Goal) (StreakUpdate, error) {
+	days, err := CompletedDays(goal)
+	if err != nil {
+		return StreakUpdate{}, err
+	}
+
+	return RebuildStreaks(goal, days)
+}
+
+// RebuildStreaks returns the update which replaces the streaks of a goal with
+// the streaks of the given completed days, such as the days replayed from its
+// completion events, laid out for the schedule of the goal. The update clears
+// the undo history of the goal, so that the streaks being replaced can not be
+// restored by an undo.
+func RebuildStreaks(goal Goal, days map[string]bool) (StreakUpdate, error) {
+	streaks, streakDates, err := StreaksFromDays(days, goal.Schedule)
+	if err != nil {
+		return StreakUpdate{}, err
+	}
+
+	best := 0
+	for _, streak := range streaks {
+		if streak.Length > best {
+			best = streak.Length
+		}
+	}
+
+	return StreakUpdate{
+		Set:         streaks,
+		Replace:     true,
+		StreakDates: streakDates,
+		BestStreak:  best,
+		Version:     goal.Version,
+	}, nil
+}
+
+// ApplyStreakUpdate returns goal as it is once update has been made, without
+// changing goal.
+func ApplyStreakUpdate(goal Goal, update StreakUpdate) Goal {
+	goal = copyGoal(goal)
+	if update.Replace {
+		goal.Streaks = map[string]GoalStreak{}
+	}
+
+	for _, streakDate := range update.Remove {
+		delete(goal.Streaks, streakDate)
+	}
+
+	for streakDate, streak := range update.Set {
+		goal.Streaks[streakDate] = streak
+	}
+
+	goal.StreakDates = append([]string{}, update.StreakDates...)
+	goal.BestStreak = update.BestStreak
+	goal.UndoHistory = copyUndoHistory(update.UndoHistory)
+	if update.Schedule != nil {
+		goal.Schedule = copySchedule(update.Schedule)
+	}
+	if len(update.Events) > 0 {
+		goal.EventCount = update.Events[len(update.Events)-1].Sequence
+	}
+	goal.Version++
+
+	return goal
+}
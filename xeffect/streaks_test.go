@@ -0,0 +1,142 @@
+package xeffect
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// everyThirdDay is scheduled on 2021-12-01, 2021-12-04, 2021-12-07 and so on.
+var everyThirdDay = &Schedule{EveryDays: 3, StartDate: "2021-12-01"}
+
+func TestStreaksFromDaysEveryDays(t *testing.T) {
+	tests := []struct {
+		name        string
+		days        []string
+		streaks     map[string]GoalStreak
+		streakDates []string
+	}{
+		{
+			name: "every scheduled day",
+			days: []string{"2021-12-01", "2021-12-04", "2021-12-07"},
+			streaks: map[string]GoalStreak{
+				"2021-12-01": {Length: 7, Skipped: []string{"2021-12-02", "2021-12-03", "2021-12-05", "2021-12-06"}},
+			},
+			streakDates: []string{"2021-12-01"},
+		},
+		{
+			name: "a missed scheduled day",
+			days: []string{"2021-12-01", "2021-12-04", "2021-12-10"},
+			streaks: map[string]GoalStreak{
+				"2021-12-01": {Length: 4, Skipped: []string{"2021-12-02", "2021-12-03"}},
+				"2021-12-10": {Length: 1},
+			},
+			streakDates: []string{"2021-12-10", "2021-12-01"},
+		},
+		{
+			name: "a day which is not scheduled",
+			days: []string{"2021-12-01", "2021-12-02", "2021-12-04"},
+			streaks: map[string]GoalStreak{
+				"2021-12-01": {Length: 4, Skipped: []string{"2021-12-03"}},
+			},
+			streakDates: []string{"2021-12-01"},
+		},
+		{
+			name: "starting between scheduled days",
+			days: []string{"2021-12-03", "2021-12-04"},
+			streaks: map[string]GoalStreak{
+				"2021-12-03": {Length: 2},
+			},
+			streakDates: []string{"2021-12-03"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			days := map[string]bool{}
+			for _, day := range test.days {
+				days[day] = true
+			}
+
+			streaks, streakDates, err := StreaksFromDays(days, everyThirdDay)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(streaks, test.streaks) {
+				t.Errorf("the streaks are %+v, want %+v", streaks, test.streaks)
+			}
+
+			if !reflect.DeepEqual(streakDates, test.streakDates) {
+				t.Errorf("the streak dates are %v, want %v", streakDates, test.streakDates)
+			}
+
+			goal := Goal{Streaks: streaks, StreakDates: streakDates, Schedule: everyThirdDay}
+			for _, streak := range streaks {
+				if streak.Length > goal.BestStreak {
+					goal.BestStreak = streak.Length
+				}
+			}
+
+			if problems := CheckStreaks(goal); len(problems) > 0 {
+				t.Errorf("the streaks are inconsistent: %v", problems)
+			}
+		})
+	}
+}
+
+func TestCheckStreaksEveryDays(t *testing.T) {
+	tests := []struct {
+		name        string
+		streaks     map[string]GoalStreak
+		streakDates []string
+		bestStreak  int
+		problem     string
+	}{
+		{
+			name: "skipping a scheduled day",
+			streaks: map[string]GoalStreak{
+				"2021-12-01": {Length: 10, Skipped: []string{"2021-12-02", "2021-12-03", "2021-12-05", "2021-12-06", "2021-12-07", "2021-12-08", "2021-12-09"}},
+			},
+			streakDates: []string{"2021-12-01"},
+			bestStreak:  10,
+			problem:     "streak '2021-12-01' skips '2021-12-07', which the schedule does not allow to be skipped",
+		},
+		{
+			name: "separated by days which are not scheduled",
+			streaks: map[string]GoalStreak{
+				"2021-12-01": {Length: 1},
+				"2021-12-04": {Length: 1},
+			},
+			streakDates: []string{"2021-12-04", "2021-12-01"},
+			bestStreak:  1,
+			problem:     "streak '2021-12-04' is only separated from streak '2021-12-01' by days which may be skipped, so should be merged with it",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			goal := Goal{
+				Streaks:     test.streaks,
+				StreakDates: test.streakDates,
+				BestStreak:  test.bestStreak,
+				Schedule:    everyThirdDay,
+			}
+
+			problems := CheckStreaks(goal)
+			if len(problems) != 1 || !strings.Contains(problems[0], test.problem) {
+				t.Errorf("the problems are %v, want only \"%s\"", problems, test.problem)
+			}
+
+			// Laying the same days out again resolves the problem.
+			update, err := NormaliseStreaks(goal)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if problems := CheckStreaks(ApplyStreakUpdate(goal, update)); len(problems) > 0 {
+				t.Errorf("the normalised streaks are inconsistent: %v", problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package xeffect
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// GoalSummary holds figures derived from a goal's streaks, as of Date.
+type GoalSummary struct {
+	Date           string  `json:"date"`
+	CurrentStreak  int     `json:"current_streak"`
+	TotalCompleted int     `json:"total_completed"`
+	CompletionRate float64 `json:"completion_rate"`
+	LongestGap     int     `json:"longest_gap"`
+	// Periods is only set for a goal with a target.
+	Periods *PeriodSummary `json:"periods,omitempty"`
+}
+
+// SummariseGoal works out the figures of a goal's streaks as of date.
+func SummariseGoal(goal Goal, date string) (GoalSummary, error) {
+	asOfDate, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return GoalSummary{}, err
+	}
+
+	streakDates := []string{}
+	for streakDate, streak := range goal.Streaks {
+		if streak.Length > 0 {
+			streakDates = append(streakDates, streakDate)
+		}
+	}
+	sort.Strings(streakDates)
+
+	// Goals created before the creation date was recorded are treated as having
+	// been created on the first day they were completed. Completions backfilled
+	// to before the creation date also move it back.
+	createdDate := asOfDate
+	if goal.CreatedAt != "" {
+		createdDate, err = time.Parse("2006-01-02", goal.CreatedAt)
+		if err != nil {
+			return GoalSummary{}, err
+		}
+	}
+	if len(streakDates) > 0 {
+		firstStreakDate, err := time.Parse("2006-01-02", streakDates[0])
+		if err != nil {
+			return GoalSummary{}, err
+		}
+
+		if firstStreakDate.Before(createdDate) {
+			createdDate = firstStreakDate
+		}
+	}
+
+	// Dates are handled as the number of days since the goal was created.
+	daysSinceCreated := func(date time.Time) int {
+		return int(date.Sub(createdDate).Hours() / 24)
+	}
+	asOfDay := daysSinceCreated(asOfDate)
+
+	summary := GoalSummary{
+		Date: date,
+	}
+
+	days, err := CompletedDays(goal)
+	if err != nil {
+		return GoalSummary{}, err
+	}
+
+	// The first day which is not known to be completed.
+	nextDay := 0
+	for _, streakDate := range streakDates {
+		streakStartDate, err := time.Parse("2006-01-02", streakDate)
+		if err != nil {
+			return GoalSummary{}, err
+		}
+
+		firstDay := daysSinceCreated(streakStartDate)
+		if firstDay > asOfDay {
+			break
+		}
+
+		lastDay := firstDay + goal.Streaks[streakDate].Length - 1
+		if lastDay > asOfDay {
+			lastDay = asOfDay
+		}
+
+		if gap := firstDay - nextDay; gap > summary.LongestGap {
+			summary.LongestGap = gap
+		}
+
+		// A streak is current if no day has been missed since it ended, such as
+		// when it ended the day before the date, as the date may still be
+		// completed.
+		lastDate := streakStartDate.AddDate(0, 0, lastDay-firstDay)
+		current := true
+		for day := lastDate.AddDate(0, 0, 1); day.Before(asOfDate); day = day.AddDate(0, 0, 1) {
+			if goal.Schedule.missed(day, asOfDate, days) {
+				current = false
+				break
+			}
+		}
+
+		summary.CurrentStreak = 0
+		if current {
+			summary.CurrentStreak = lastDay - firstDay + 1
+		}
+
+		// The days skipped by a streak were not completed.
+		summary.TotalCompleted += lastDay - firstDay + 1
+		for _, skipped := range goal.Streaks[streakDate].Skipped {
+			if skipped <= lastDate.Format("2006-01-02") {
+				summary.TotalCompleted--
+			}
+		}
+		nextDay = lastDay + 1
+	}
+
+	// The date itself is not counted as part of a gap, as it may still be
+	// completed.
+	if gap := asOfDay - nextDay; gap > summary.LongestGap {
+		summary.LongestGap = gap
+	}
+
+	// A goal with a schedule may be completed on more days than it needed to
+	// be, so the rate is at most one.
+	if expected := goal.Schedule.expectedDays(createdDate, asOfDate); expected > 0 {
+		summary.CompletionRate = math.Min(float64(summary.TotalCompleted)/expected, 1)
+	}
+
+	if goal.Target != nil {
+		periods := summarisePeriods(*goal.Target, days, createdDate, asOfDate)
+		summary.Periods = &periods
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,175 @@
+package xeffect
+
+import (
+	"math"
+	"testing"
+)
+
+// goalCompletedOn returns a goal created on createdAt which was completed on
+// the given dates, laid out for schedule.
+func goalCompletedOn(t *testing.T, createdAt string, schedule *Schedule, dates ...string) Goal {
+	t.Helper()
+
+	days := map[string]bool{}
+	for _, date := range dates {
+		days[date] = true
+	}
+
+	streaks, streakDates, err := StreaksFromDays(days, schedule)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return Goal{
+		Uuid:        "goal-1",
+		Streaks:     streaks,
+		StreakDates: streakDates,
+		CreatedAt:   createdAt,
+		Schedule:    schedule,
+	}
+}
+
+func TestSummariseGoal(t *testing.T) {
+	tests := []struct {
+		name           string
+		goal           Goal
+		date           string
+		currentStreak  int
+		totalCompleted int
+		completionRate float64
+		longestGap     int
+	}{
+		{
+			name: "never completed",
+			goal: goalCompletedOn(t, "2021-12-01", nil),
+			date: "2021-12-01",
+		},
+		{
+			name:           "completed every day so far",
+			goal:           goalCompletedOn(t, "2021-12-01", nil, "2021-12-01", "2021-12-02", "2021-12-03"),
+			date:           "2021-12-03",
+			currentStreak:  3,
+			totalCompleted: 3,
+			completionRate: 1,
+		},
+		{
+			name:           "not yet completed today",
+			goal:           goalCompletedOn(t, "2021-12-01", nil, "2021-12-01", "2021-12-02", "2021-12-03"),
+			date:           "2021-12-04",
+			currentStreak:  3,
+			totalCompleted: 3,
+			completionRate: 0.75,
+		},
+		{
+			name:           "missed yesterday",
+			goal:           goalCompletedOn(t, "2021-12-01", nil, "2021-12-01", "2021-12-02", "2021-12-03"),
+			date:           "2021-12-05",
+			totalCompleted: 3,
+			completionRate: 0.6,
+			longestGap:     1,
+		},
+		{
+			name:           "a gap between streaks",
+			goal:           goalCompletedOn(t, "2021-12-01", nil, "2021-12-01", "2021-12-02", "2021-12-06", "2021-12-07"),
+			date:           "2021-12-07",
+			currentStreak:  2,
+			totalCompleted: 4,
+			completionRate: 4.0 / 7,
+			longestGap:     3,
+		},
+		{
+			name:           "days after the date are not counted",
+			goal:           goalCompletedOn(t, "2021-12-01", nil, "2021-12-01", "2021-12-02", "2021-12-03", "2021-12-04"),
+			date:           "2021-12-02",
+			currentStreak:  2,
+			totalCompleted: 2,
+			completionRate: 1,
+		},
+		{
+			name:           "completed before the goal was created",
+			goal:           goalCompletedOn(t, "2021-12-05", nil, "2021-12-03"),
+			date:           "2021-12-03",
+			currentStreak:  1,
+			totalCompleted: 1,
+			completionRate: 1,
+		},
+		{
+			// 2021-12-01 is a Wednesday, so the goal is to be completed on 2021-12-01,
+			// 2021-12-03 and 2021-12-06 before the date, skipping the days between.
+			name:           "weekdays",
+			goal:           goalCompletedOn(t, "2021-12-01", &Schedule{Weekdays: []string{"monday", "wednesday", "friday"}}, "2021-12-01", "2021-12-03", "2021-12-06"),
+			date:           "2021-12-07",
+			currentStreak:  6,
+			totalCompleted: 3,
+			completionRate: 1,
+		},
+		{
+			name:           "weekdays with a missed day",
+			goal:           goalCompletedOn(t, "2021-12-01", &Schedule{Weekdays: []string{"monday", "wednesday", "friday"}}, "2021-12-01", "2021-12-06"),
+			date:           "2021-12-07",
+			currentStreak:  1,
+			totalCompleted: 2,
+			completionRate: 2.0 / 3,
+			longestGap:     4,
+		},
+		{
+			name:           "every other day",
+			goal:           goalCompletedOn(t, "2021-12-01", &Schedule{EveryDays: 2, StartDate: "2021-12-01"}, "2021-12-01", "2021-12-03", "2021-12-05"),
+			date:           "2021-12-06",
+			currentStreak:  5,
+			totalCompleted: 3,
+			completionRate: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			summary, err := SummariseGoal(test.goal, test.date)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if summary.Date != test.date {
+				t.Errorf("the summary is as of %s, want %s", summary.Date, test.date)
+			}
+
+			if summary.CurrentStreak != test.currentStreak || summary.TotalCompleted != test.totalCompleted || summary.LongestGap != test.longestGap {
+				t.Errorf("the current streak, total and longest gap are %d, %d and %d, want %d, %d and %d",
+					summary.CurrentStreak, summary.TotalCompleted, summary.LongestGap,
+					test.currentStreak, test.totalCompleted, test.longestGap)
+			}
+
+			if math.Abs(summary.CompletionRate-test.completionRate) > 1e-9 {
+				t.Errorf("the completion rate is %f, want %f", summary.CompletionRate, test.completionRate)
+			}
+
+			if summary.Periods != nil {
+				t.Errorf("a goal without a target has the periods %+v", summary.Periods)
+			}
+		})
+	}
+}
+
+func TestSummariseGoalTarget(t *testing.T) {
+	goal := goalCompletedOn(t, "2021-12-06", nil, "2021-12-06", "2021-12-07", "2021-12-08")
+	goal.Target = &Target{Count: 3, Period: TARGET_PERIOD_WEEK}
+
+	summary, err := SummariseGoal(goal, "2021-12-08")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Periods == nil || summary.Periods.CurrentStreak != 1 || len(summary.Periods.Periods) != 1 {
+		t.Fatalf("the periods are %+v, want the current week, met", summary.Periods)
+	}
+}
+
+func TestSummariseGoalInvalidDate(t *testing.T) {
+	if _, err := SummariseGoal(goalCompletedOn(t, "2021-12-01", nil), "1 Dec"); err == nil {
+		t.Error("a summary was made as of a date which is not a date")
+	}
+
+	if _, err := SummariseGoal(goalCompletedOn(t, "1 Dec", nil), "2021-12-01"); err == nil {
+		t.Error("a summary was made of a goal created on a date which is not a date")
+	}
+}
@@ -0,0 +1,156 @@
+package xeffect
+
+import (
+	"fmt"
+	"time"
+)
+
+// The periods a target can be set over. Weeks start on a Monday, and months on
+// their first day.
+const (
+	TARGET_PERIOD_WEEK  = "week"
+	TARGET_PERIOD_MONTH = "month"
+)
+
+// MAX_SUMMARY_PERIODS is the number of the most recent periods listed in the
+// summary of a goal with a target.
+const MAX_SUMMARY_PERIODS = 12
+
+// Target is a number of days a goal is to be completed on in each week or
+// month, on any days of the period. A month with fewer days than Count is met
+// by completing every day of it.
+type Target struct {
+	Count  int    `json:"count,omitempty" dynamodbav:",omitempty"`
+	Period string `json:"period,omitempty" dynamodbav:",omitempty"`
+}
+
+// IsEmpty reports whether none of the fields of the target are set.
+func (t *Target) IsEmpty() bool {
+	return t.Count == 0 && t.Period == ""
+}
+
+// copyTarget returns a copy of target, or nil when target is nil or empty.
+func copyTarget(target *Target) *Target {
+	if target == nil || target.IsEmpty() {
+		return nil
+	}
+
+	copied := *target
+
+	return &copied
+}
+
+// Validate returns an error describing why the target can not be used, or nil
+// when it can.
+func (t *Target) Validate() error {
+	days := 0
+	switch t.Period {
+	case TARGET_PERIOD_WEEK:
+		days = 7
+	case TARGET_PERIOD_MONTH:
+		days = 31
+	default:
+		return fmt.Errorf("'period' must be '%s' or '%s'", TARGET_PERIOD_WEEK, TARGET_PERIOD_MONTH)
+	}
+
+	if t.Count < 1 || t.Count > days {
+		return fmt.Errorf("'count' must be between 1 and %d for a %s", days, t.Period)
+	}
+
+	return nil
+}
+
+// periodStart returns the first day of the period date falls in.
+func (t *Target) periodStart(date time.Time) time.Time {
+	if t.Period == TARGET_PERIOD_MONTH {
+		return date.AddDate(0, 0, 1-date.Day())
+	}
+
+	return weekStart(date)
+}
+
+// periodEnd returns the first day of the period after the one starting on start.
+func (t *Target) periodEnd(start time.Time) time.Time {
+	if t.Period == TARGET_PERIOD_MONTH {
+		return start.AddDate(0, 1, 0)
+	}
+
+	return start.AddDate(0, 0, 7)
+}
+
+// PeriodSummary holds figures of a goal with a target, alongside its daily
+// streaks. A period streak is a run of consecutive periods in which the target
+// was met.
+type PeriodSummary struct {
+	Period        string `json:"period"`
+	Target        int    `json:"target"`
+	CurrentStreak int    `json:"current_streak"`
+	BestStreak    int    `json:"best_streak"`
+	// Periods are the most recent periods, up to and including the one the
+	// summary is as of, most recent first.
+	Periods []Period `json:"periods"`
+}
+
+// Period is a single week or month of a goal with a target, along with the
+// number of days it was completed on. Required is the number of days needed to
+// meet the target, which is fewer than the target in a month too short for it.
+type Period struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Required  int    `json:"required"`
+	Completed int    `json:"completed"`
+	Met       bool   `json:"met"`
+}
+
+// summarisePeriods works out the period figures of a goal completed on days, in
+// every period from the one including createdDate to the one including asOf.
+// Only days up to asOf are counted. A period streak is current if it includes
+// the period of asOf, or ended the period before, as the target may still be
+// met.
+func summarisePeriods(target Target, days map[string]bool, createdDate time.Time, asOfDate time.Time) PeriodSummary {
+	summary := PeriodSummary{
+		Period:  target.Period,
+		Target:  target.Count,
+		Periods: []Period{},
+	}
+
+	last := target.periodStart(asOfDate)
+	streak := 0
+	for start := target.periodStart(createdDate); !start.After(last); start = target.periodEnd(start) {
+		end := target.periodEnd(start)
+
+		period := Period{
+			StartDate: start.Format("2006-01-02"),
+			EndDate:   end.AddDate(0, 0, -1).Format("2006-01-02"),
+			Required:  target.Count,
+		}
+		if length := int(end.Sub(start).Hours() / 24); length < period.Required {
+			period.Required = length
+		}
+
+		for date := start; date.Before(end) && !date.After(asOfDate); date = date.AddDate(0, 0, 1) {
+			if days[date.Format("2006-01-02")] {
+				period.Completed++
+			}
+		}
+		period.Met = period.Completed >= period.Required
+
+		if period.Met {
+			streak++
+		} else if start.Before(last) {
+			streak = 0
+		}
+
+		if streak > summary.BestStreak {
+			summary.BestStreak = streak
+		}
+		summary.CurrentStreak = streak
+
+		summary.Periods = append([]Period{period}, summary.Periods...)
+		if len(summary.Periods) > MAX_SUMMARY_PERIODS {
+			summary.Periods = summary.Periods[:MAX_SUMMARY_PERIODS]
+		}
+	}
+
+	return summary
+}
@@ -0,0 +1,167 @@
+package xeffect
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testDate(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return parsed
+}
+
+// completeDays adds every date from from to to inclusive to days.
+func completeDays(t *testing.T, from string, to string, days map[string]bool) map[string]bool {
+	t.Helper()
+
+	for day := testDate(t, from); !day.After(testDate(t, to)); day = day.AddDate(0, 0, 1) {
+		days[day.Format("2006-01-02")] = true
+	}
+
+	return days
+}
+
+func TestSummarisePeriodsWeeks(t *testing.T) {
+	target := Target{Count: 3, Period: TARGET_PERIOD_WEEK}
+
+	// The goal is created on Thursday 2021-11-04, so its first week started
+	// before it did. Days before it was created still count towards the week,
+	// as they can be marked after the goal is created.
+	days := map[string]bool{
+		"2021-11-02": true,
+		"2021-11-04": true,
+		"2021-11-05": true,
+		// 2021-11-08 to 2021-11-14 is met.
+		"2021-11-08": true,
+		"2021-11-10": true,
+		"2021-11-12": true,
+		// 2021-11-15 to 2021-11-21 is missed.
+		"2021-11-15": true,
+		// 2021-11-22 to 2021-11-28 is met.
+		"2021-11-22": true,
+		"2021-11-23": true,
+		"2021-11-24": true,
+		"2021-11-25": true,
+		// 2021-11-29 to 2021-12-05 is met.
+		"2021-11-29": true,
+		"2021-11-30": true,
+		"2021-12-01": true,
+		// The current week has only been completed once so far.
+		"2021-12-06": true,
+	}
+
+	summary := summarisePeriods(target, days, testDate(t, "2021-11-04"), testDate(t, "2021-12-08"))
+
+	want := []Period{
+		{StartDate: "2021-12-06", EndDate: "2021-12-12", Required: 3, Completed: 1, Met: false},
+		{StartDate: "2021-11-29", EndDate: "2021-12-05", Required: 3, Completed: 3, Met: true},
+		{StartDate: "2021-11-22", EndDate: "2021-11-28", Required: 3, Completed: 4, Met: true},
+		{StartDate: "2021-11-15", EndDate: "2021-11-21", Required: 3, Completed: 1, Met: false},
+		{StartDate: "2021-11-08", EndDate: "2021-11-14", Required: 3, Completed: 3, Met: true},
+		{StartDate: "2021-11-01", EndDate: "2021-11-07", Required: 3, Completed: 3, Met: true},
+	}
+
+	if !reflect.DeepEqual(summary.Periods, want) {
+		t.Errorf("the periods are %+v, want %+v", summary.Periods, want)
+	}
+
+	// The current week may still be met, so it does not end the current streak.
+	if summary.CurrentStreak != 2 || summary.BestStreak != 2 {
+		t.Errorf("the current and best streaks are %d and %d, want 2 and 2", summary.CurrentStreak, summary.BestStreak)
+	}
+
+	// Once it is met, the current week adds to the current streak.
+	days["2021-12-07"] = true
+	days["2021-12-08"] = true
+	summary = summarisePeriods(target, days, testDate(t, "2021-11-04"), testDate(t, "2021-12-08"))
+
+	if summary.CurrentStreak != 3 || summary.BestStreak != 3 {
+		t.Errorf("the current and best streaks are %d and %d, want 3 and 3", summary.CurrentStreak, summary.BestStreak)
+	}
+
+	// Days after the summary is as of are not counted.
+	summary = summarisePeriods(target, days, testDate(t, "2021-11-04"), testDate(t, "2021-12-07"))
+
+	if summary.Periods[0].Completed != 2 {
+		t.Errorf("the current week has %d days completed, want 2", summary.Periods[0].Completed)
+	}
+
+	// A week which has ended without being met ends the streak.
+	summary = summarisePeriods(target, days, testDate(t, "2021-11-04"), testDate(t, "2021-12-21"))
+
+	if summary.CurrentStreak != 0 || summary.BestStreak != 3 {
+		t.Errorf("the current and best streaks are %d and %d, want 0 and 3", summary.CurrentStreak, summary.BestStreak)
+	}
+}
+
+func TestSummarisePeriodsMonths(t *testing.T) {
+	target := Target{Count: 31, Period: TARGET_PERIOD_MONTH}
+
+	// Every day from the start of December to the middle of May is completed,
+	// and a goal completed every day meets the target in every month, however
+	// short.
+	days := completeDays(t, "2021-12-01", "2022-05-15", map[string]bool{})
+
+	summary := summarisePeriods(target, days, testDate(t, "2021-12-01"), testDate(t, "2022-05-15"))
+
+	want := []Period{
+		{StartDate: "2022-05-01", EndDate: "2022-05-31", Required: 31, Completed: 15, Met: false},
+		{StartDate: "2022-04-01", EndDate: "2022-04-30", Required: 30, Completed: 30, Met: true},
+		{StartDate: "2022-03-01", EndDate: "2022-03-31", Required: 31, Completed: 31, Met: true},
+		{StartDate: "2022-02-01", EndDate: "2022-02-28", Required: 28, Completed: 28, Met: true},
+		{StartDate: "2022-01-01", EndDate: "2022-01-31", Required: 31, Completed: 31, Met: true},
+		{StartDate: "2021-12-01", EndDate: "2021-12-31", Required: 31, Completed: 31, Met: true},
+	}
+
+	if !reflect.DeepEqual(summary.Periods, want) {
+		t.Errorf("the periods are %+v, want %+v", summary.Periods, want)
+	}
+
+	if summary.CurrentStreak != 5 || summary.BestStreak != 5 {
+		t.Errorf("the current and best streaks are %d and %d, want 5 and 5", summary.CurrentStreak, summary.BestStreak)
+	}
+
+	// February has 29 days in a leap year.
+	days = completeDays(t, "2024-02-01", "2024-02-28", map[string]bool{})
+	target = Target{Count: 29, Period: TARGET_PERIOD_MONTH}
+
+	summary = summarisePeriods(target, days, testDate(t, "2024-02-01"), testDate(t, "2024-03-01"))
+
+	if february := summary.Periods[1]; february.Required != 29 || february.Met {
+		t.Errorf("February 2024 is %+v, want 29 days required and not met", february)
+	}
+
+	days["2024-02-29"] = true
+	summary = summarisePeriods(target, days, testDate(t, "2024-02-01"), testDate(t, "2024-03-01"))
+
+	if february := summary.Periods[1]; !february.Met || summary.CurrentStreak != 1 {
+		t.Errorf("February 2024 is %+v with a current streak of %d, want met with 1", february, summary.CurrentStreak)
+	}
+}
+
+func TestSummarisePeriodsLimit(t *testing.T) {
+	target := Target{Count: 1, Period: TARGET_PERIOD_WEEK}
+	days := completeDays(t, "2021-01-04", "2021-12-31", map[string]bool{})
+
+	summary := summarisePeriods(target, days, testDate(t, "2021-01-04"), testDate(t, "2021-12-31"))
+
+	if len(summary.Periods) != MAX_SUMMARY_PERIODS {
+		t.Fatalf("%d periods are listed, want %d", len(summary.Periods), MAX_SUMMARY_PERIODS)
+	}
+
+	if summary.Periods[0].StartDate != "2021-12-27" {
+		t.Errorf("the most recent period starts on %s, want 2021-12-27", summary.Periods[0].StartDate)
+	}
+
+	// Every week of the year is counted in the streaks, not only those listed.
+	if summary.CurrentStreak != 52 || summary.BestStreak != 52 {
+		t.Errorf("the current and best streaks are %d and %d, want 52 and 52", summary.CurrentStreak, summary.BestStreak)
+	}
+}
@@ -0,0 +1,55 @@
+// Package xeffecttest provides the goals, repositories and requests shared by
+// the tests of the lambda handlers.
+package xeffecttest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/maxstanley/xeffect_backend/xeffect"
+)
+
+// OWNER is the owner of the goals made by NewGoalRepository.
+const OWNER = "owner-1"
+
+// NewGoal returns a goal created on 2021-12-01 with no completed days.
+func NewGoal(id string) xeffect.Goal {
+	return xeffect.Goal{
+		Uuid:        id,
+		Title:       "Read",
+		Motivation:  "Learn",
+		Streaks:     map[string]xeffect.GoalStreak{},
+		StreakDates: []string{},
+		CreatedAt:   "2021-12-01",
+	}
+}
+
+// NewGoalRepository returns a repository backed by memory, holding goals
+// owned by OWNER.
+func NewGoalRepository(t testing.TB, goals ...xeffect.Goal) *xeffect.MemoryGoalRepository {
+	t.Helper()
+
+	repository := xeffect.NewMemoryGoalRepository()
+	CreateGoals(t, repository, OWNER, goals...)
+
+	return repository
+}
+
+// CreateGoals stores goals for owner, failing the test if any is not stored.
+func CreateGoals(t testing.TB, repository xeffect.GoalRepository, owner string, goals ...xeffect.Goal) {
+	t.Helper()
+
+	for _, goal := range goals {
+		if err := repository.ForOwner(owner).CreateGoal(context.Background(), goal); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// RequestContext returns the context of a request authorized for owner.
+func RequestContext(owner string) events.APIGatewayProxyRequestContext {
+	return events.APIGatewayProxyRequestContext{
+		Authorizer: map[string]interface{}{"principalId": owner},
+	}
+}